@@ -64,7 +64,7 @@ func RunFile(fileName string) {
 
 	defer wrapPanic()
 
-	program := p.ParseProgram()
+	program := evaluator.ExpandMacros(p.ParseProgram())
 
 	if gs.Debug {
 		fmt.Print(
@@ -75,9 +75,15 @@ func RunFile(fileName string) {
 				32,
 			),
 		)
+		fmt.Printf(
+			"%s %s\n",
+			color.RedString("[pragmas]"),
+			program.Pragmas,
+		)
 	}
 
 	e := evaluator.New(nil)
+	e.SetPragmas(program.Pragmas)
 
 	if !gs.Debug {
 		e.Eval(program)
@@ -88,12 +94,12 @@ func RunFile(fileName string) {
 	for idx, stmt := range program.Statements {
 		obj := e.Eval(stmt)
 		result = obj
-		fmt.Printf("%d >> %s\n", idx+1, obj.Inspect())
+		fmt.Printf("%d >> %s\n", idx+1, present(obj))
 	}
 	fmt.Printf(
 		"%s >>> %s\n",
 		color.RedString("[program result]"),
-		result.Inspect(),
+		present(result),
 	)
 
 	fmt.Printf(
@@ -102,6 +108,19 @@ func RunFile(fileName string) {
 	)
 }
 
+// colors value by type, color.NoColor turns it off
+func present(obj environment.Object) string {
+	switch obj.Type() {
+	case environment.NUMBER:
+		return color.GreenString(obj.Inspect())
+	case environment.BOOLEAN, environment.FUNCTION:
+		return color.MagentaString(obj.Inspect())
+	case environment.NIL:
+		return color.BlueString(obj.Inspect())
+	}
+	return obj.Inspect()
+}
+
 func wrapPanic() {
 	if p := recover(); p != nil {
 		fmt.Printf("%s\n", p)
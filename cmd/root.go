@@ -7,6 +7,7 @@ import (
 	"wildscript/cmd/interpreter"
 	"wildscript/internal/settings"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +17,10 @@ var rootCmd = &cobra.Command{
 	Long:  `GO, GO WILD, WILDSCRIPT!`,
 	Args:  cobra.ArbitraryArgs,
 
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return setColor(settings.Global.Color)
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			fmt.Println("no file")
@@ -32,6 +37,23 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// auto keeps detection of fatih/color: NO_COLOR and non-terminal stdout
+// turn colors off, explicit mode overrides both
+func setColor(mode string) error {
+	switch mode {
+	case "auto":
+	case "always":
+		// fatih/color checks NO_COLOR for every color it creates
+		os.Unsetenv("NO_COLOR")
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid color mode %q, want auto, always or never", mode)
+	}
+	return nil
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -52,4 +74,10 @@ func init() {
 		false,
 		"show lexer tokens",
 	)
+	rootCmd.Flags().StringVar(
+		&settings.Global.Color,
+		"color",
+		settings.Global.Color,
+		"colorize output: auto, always or never",
+	)
 }
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"strings"
 	"wildscript/internal/lexer"
 )
@@ -22,6 +23,7 @@ type Expression interface {
 type Program struct {
 	Token      lexer.Token
 	Statements []Statement
+	Pragmas    Pragmas
 }
 
 func (p *Program) String() string {
@@ -34,3 +36,43 @@ func (p *Program) String() string {
 	}
 	return sb.String()
 }
+
+const LatestEdition = 2
+
+// behaviors toggled by edition directive and pragma statements
+type Pragmas struct {
+	Edition      int
+	Strict       bool // conditions must be boolean
+	ASI          bool // new line ends statement
+	ShortCircuit bool // and, or skip right operand
+}
+
+// edition 1 has no asi and evaluates both operands of and, or
+func EditionPragmas(edition int) Pragmas {
+	return Pragmas{
+		Edition:      edition,
+		Strict:       true,
+		ASI:          edition >= 2,
+		ShortCircuit: edition >= 2,
+	}
+}
+
+func (p Pragmas) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("edition %d", p.Edition))
+	for _, pragma := range []struct {
+		name string
+		on   bool
+	}{
+		{"strict", p.Strict},
+		{"asi", p.ASI},
+		{"short_circuit", p.ShortCircuit},
+	} {
+		sb.WriteString(", ")
+		if !pragma.on {
+			sb.WriteString("no_")
+		}
+		sb.WriteString(pragma.name)
+	}
+	return sb.String()
+}
@@ -110,15 +110,17 @@ type SliceExpression struct {
 	Left  Expression
 	Start Expression
 	End   Expression
+	Step  Expression
 }
 
 func (se *SliceExpression) expressionNode() {}
 func (se *SliceExpression) String() string {
 	return fmt.Sprintf(
-		"%s[%s:%s]",
+		"%s[%s:%s:%s]",
 		se.Left.String(),
 		se.Start.String(),
 		se.End.String(),
+		se.Step.String(),
 	)
 }
 
@@ -145,9 +147,38 @@ type KeyExpression struct {
 
 func (ke *KeyExpression) expressionNode() {}
 func (ke *KeyExpression) String() string {
+	if ke.Whole() {
+		return fmt.Sprintf("%s{}", ke.Left.String())
+	}
 	return fmt.Sprintf(
 		"%s{%s}",
 		ke.Left.String(),
 		ke.Key.String(),
 	)
 }
+
+// doc{} refers to whole dict, its key is nil literal without token
+func (ke *KeyExpression) Whole() bool {
+	key, ok := ke.Key.(*NilLiteral)
+	return ok && key.Token.Type == ""
+}
+
+type QuoteExpression struct {
+	Token lexer.Token
+	Body  *BlockExpression
+}
+
+func (qe *QuoteExpression) expressionNode() {}
+func (qe *QuoteExpression) String() string {
+	return fmt.Sprintf("quote %s", qe.Body.String())
+}
+
+type UnquoteExpression struct {
+	Token lexer.Token
+	Value Expression
+}
+
+func (ue *UnquoteExpression) expressionNode() {}
+func (ue *UnquoteExpression) String() string {
+	return fmt.Sprintf("unquote(%s)", ue.Value.String())
+}
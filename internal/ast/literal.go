@@ -20,6 +20,7 @@ const (
 	FUNCTION FunctionImplementation = "function"
 	LAMBDA   FunctionImplementation = "lambda"
 	METHOD   FunctionImplementation = "method"
+	STATIC   FunctionImplementation = "static"
 )
 
 type Identifier struct {
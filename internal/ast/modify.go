@@ -0,0 +1,289 @@
+package ast
+
+// replaces node and returns whether to walk into its children
+type ModifierFunc func(Node) (Node, bool)
+
+// walks tree in pre-order replacing nodes by modifier,
+// child lists are rebuilt so that a modifier returning copies clones the tree
+func Modify(node Node, modifier ModifierFunc) Node {
+	node, walk := modifier(node)
+	if !walk {
+		return node
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		node.Statements = modifyStatements(node.Statements, modifier)
+	case *BlockExpression:
+		node.Statements = modifyStatements(node.Statements, modifier)
+
+	case *ExpressionStatement:
+		node.Expression = modifyExpression(node.Expression, modifier)
+	case *AssignStatement:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+	case *LetStatement:
+		node.Left = modifyIdentifier(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+	case *FunctionStatement:
+		node.Identifier = modifyIdentifier(node.Identifier, modifier)
+		if function, ok := Modify(node.Function, modifier).(*FunctionLiteral); ok {
+			node.Function = function
+		}
+	case *ReturnStatement:
+		node.Value = modifyExpression(node.Value, modifier)
+	case *ExportStatement:
+		node.Value = modifyExpression(node.Value, modifier)
+	case *ForStatement:
+		node.Key = modifyIdentifier(node.Key, modifier)
+		node.Value = modifyIdentifier(node.Value, modifier)
+		node.Iterable = modifyExpression(node.Iterable, modifier)
+		node.Loop = modifyBlock(node.Loop, modifier)
+	case *RepeatStatement:
+		node.Loop = modifyBlock(node.Loop, modifier)
+		node.Until = modifyExpression(node.Until, modifier)
+	case *WhileStatement:
+		node.If = modifyExpression(node.If, modifier)
+		node.Loop = modifyBlock(node.Loop, modifier)
+	case *WithStatement:
+		node.Resource = modifyExpression(node.Resource, modifier)
+		node.Name = modifyIdentifier(node.Name, modifier)
+		node.Body = modifyBlock(node.Body, modifier)
+	case *EnumStatement:
+		node.Name = modifyIdentifier(node.Name, modifier)
+		members := make([]*EnumMember, len(node.Members))
+		for idx, member := range node.Members {
+			m := *member
+			if m.Value != nil {
+				m.Value = modifyExpression(m.Value, modifier)
+			}
+			members[idx] = &m
+		}
+		node.Members = members
+	case *ProtocolStatement:
+		node.Name = modifyIdentifier(node.Name, modifier)
+	case *DeleteStatement:
+		node.Target = modifyExpression(node.Target, modifier)
+	case *OperatorStatement:
+		node.Function = modifyExpression(node.Function, modifier)
+
+	case *InfixExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+	case *PrefixExpression:
+		node.Right = modifyExpression(node.Right, modifier)
+	case *CallExpression:
+		node.Function = modifyExpression(node.Function, modifier)
+		node.Arguments = modifyExpressions(node.Arguments, modifier)
+	case *IfExpression:
+		node.If = modifyExpression(node.If, modifier)
+		node.Then = modifyBlock(node.Then, modifier)
+		node.Else = modifyExpression(node.Else, modifier)
+	case *IndexExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Index = modifyExpression(node.Index, modifier)
+	case *SliceExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Start = modifyExpression(node.Start, modifier)
+		node.End = modifyExpression(node.End, modifier)
+		node.Step = modifyExpression(node.Step, modifier)
+	case *AttributeExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+	case *KeyExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Key = modifyExpression(node.Key, modifier)
+	case *QuoteExpression:
+		node.Body = modifyBlock(node.Body, modifier)
+	case *UnquoteExpression:
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *FunctionLiteral:
+		params := make([]*Identifier, len(node.Parameters))
+		for idx, param := range node.Parameters {
+			params[idx] = modifyIdentifier(param, modifier)
+		}
+		node.Parameters = params
+		node.Body = modifyBlock(node.Body, modifier)
+	case *DocumentLiteral:
+		elems := make([]*DocumentElement, len(node.Elements))
+		for idx, elem := range node.Elements {
+			e := *elem
+			if e.Type == DICT {
+				e.Key = modifyExpression(e.Key, modifier)
+			}
+			e.Value = modifyExpression(e.Value, modifier)
+			elems[idx] = &e
+		}
+		node.Elements = elems
+	}
+
+	return node
+}
+
+// deep copy of the tree
+func Clone(node Node) Node {
+	return Modify(node, func(node Node) (Node, bool) {
+		return shallowCopy(node), true
+	})
+}
+
+func shallowCopy(node Node) Node {
+	switch node := node.(type) {
+	case *Program:
+		n := *node
+		return &n
+	case *BlockExpression:
+		n := *node
+		return &n
+	case *ExpressionStatement:
+		n := *node
+		return &n
+	case *AssignStatement:
+		n := *node
+		return &n
+	case *LetStatement:
+		n := *node
+		return &n
+	case *FunctionStatement:
+		n := *node
+		return &n
+	case *ReturnStatement:
+		n := *node
+		return &n
+	case *ContinueStatement:
+		n := *node
+		return &n
+	case *BreakStatement:
+		n := *node
+		return &n
+	case *ImportStatement:
+		n := *node
+		return &n
+	case *ExportStatement:
+		n := *node
+		return &n
+	case *ForStatement:
+		n := *node
+		return &n
+	case *RepeatStatement:
+		n := *node
+		return &n
+	case *WhileStatement:
+		n := *node
+		return &n
+	case *WithStatement:
+		n := *node
+		return &n
+	case *EnumStatement:
+		n := *node
+		return &n
+	case *ProtocolStatement:
+		n := *node
+		return &n
+	case *DeleteStatement:
+		n := *node
+		return &n
+	case *OperatorStatement:
+		n := *node
+		return &n
+	case *MacroStatement:
+		n := *node
+		return &n
+	case *InfixExpression:
+		n := *node
+		return &n
+	case *PrefixExpression:
+		n := *node
+		return &n
+	case *CallExpression:
+		n := *node
+		return &n
+	case *IfExpression:
+		n := *node
+		return &n
+	case *IndexExpression:
+		n := *node
+		return &n
+	case *SliceExpression:
+		n := *node
+		return &n
+	case *AttributeExpression:
+		n := *node
+		return &n
+	case *KeyExpression:
+		n := *node
+		return &n
+	case *QuoteExpression:
+		n := *node
+		return &n
+	case *UnquoteExpression:
+		n := *node
+		return &n
+	case *Identifier:
+		n := *node
+		return &n
+	case *NumberLiteral:
+		n := *node
+		return &n
+	case *StringLiteral:
+		n := *node
+		return &n
+	case *BooleanLiteral:
+		n := *node
+		return &n
+	case *NilLiteral:
+		n := *node
+		return &n
+	case *FunctionLiteral:
+		n := *node
+		return &n
+	case *DocumentLiteral:
+		n := *node
+		return &n
+	default:
+		return node
+	}
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) []Statement {
+	result := make([]Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		if s, ok := Modify(stmt, modifier).(Statement); ok {
+			stmt = s
+		}
+		result = append(result, stmt)
+	}
+	return result
+}
+
+func modifyExpressions(exprs []Expression, modifier ModifierFunc) []Expression {
+	result := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		result = append(result, modifyExpression(expr, modifier))
+	}
+	return result
+}
+
+func modifyExpression(expr Expression, modifier ModifierFunc) Expression {
+	if e, ok := Modify(expr, modifier).(Expression); ok {
+		return e
+	}
+	return expr
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	if i, ok := Modify(ident, modifier).(*Identifier); ok {
+		return i
+	}
+	return ident
+}
+
+func modifyBlock(block *BlockExpression, modifier ModifierFunc) *BlockExpression {
+	if b, ok := Modify(block, modifier).(*BlockExpression); ok {
+		return b
+	}
+	return block
+}
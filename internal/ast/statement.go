@@ -109,6 +109,7 @@ func (es *ExportStatement) String() string {
 
 type ForStatement struct {
 	Token    lexer.Token
+	Key      *Identifier
 	Value    *Identifier
 	Iterable Expression
 	Loop     *BlockExpression
@@ -116,7 +117,15 @@ type ForStatement struct {
 
 func (fs *ForStatement) statementNode() {}
 func (fs *ForStatement) String() string {
-	if fs.Value != nil {
+	if fs.Key != nil {
+		return fmt.Sprintf(
+			"for %s, %s in %s do %s",
+			fs.Key.String(),
+			fs.Value.String(),
+			fs.Iterable.String(),
+			fs.Loop.String(),
+		)
+	} else if fs.Value != nil {
 		return fmt.Sprintf(
 			"for %s in %s do %s",
 			fs.Value.String(),
@@ -161,3 +170,148 @@ func (ws *WhileStatement) String() string {
 		ws.Loop.String(),
 	)
 }
+
+// target is attribute, key, index or slice expression
+type DeleteStatement struct {
+	Token  lexer.Token
+	Target Expression
+}
+
+func (ds *DeleteStatement) statementNode() {}
+func (ds *DeleteStatement) String() string {
+	return "del " + ds.Target.String()
+}
+
+type WithStatement struct {
+	Token    lexer.Token
+	Resource Expression
+	Name     *Identifier
+	Body     *BlockExpression
+}
+
+func (ws *WithStatement) statementNode() {}
+func (ws *WithStatement) String() string {
+	if ws.Name != nil {
+		return fmt.Sprintf(
+			"with %s as %s do %s",
+			ws.Resource.String(),
+			ws.Name.String(),
+			ws.Body.String(),
+		)
+	}
+	return fmt.Sprintf(
+		"with %s do %s",
+		ws.Resource.String(),
+		ws.Body.String(),
+	)
+}
+
+type EnumMember struct {
+	Token lexer.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (em *EnumMember) String() string {
+	if em.Value != nil {
+		return fmt.Sprintf("%s = %s", em.Name.String(), em.Value.String())
+	}
+	return em.Name.String()
+}
+
+type EnumStatement struct {
+	Token   lexer.Token
+	Name    *Identifier
+	Members []*EnumMember
+}
+
+func (es *EnumStatement) statementNode() {}
+func (es *EnumStatement) String() string {
+	var sb strings.Builder
+	sb.WriteString("enum " + es.Name.String() + " {")
+	for idx, member := range es.Members {
+		sb.WriteString(member.String())
+		if idx != len(es.Members)-1 {
+			sb.WriteString(", ")
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// member without parameters is plain attribute
+type ProtocolMember struct {
+	Token      lexer.Token
+	Name       *Identifier
+	Parameters []*Identifier
+}
+
+func (pm *ProtocolMember) String() string {
+	if pm.Parameters == nil {
+		return pm.Name.String()
+	}
+	params := make([]string, 0, len(pm.Parameters))
+	for _, param := range pm.Parameters {
+		params = append(params, param.String())
+	}
+	return fmt.Sprintf("%s(%s)", pm.Name.String(), strings.Join(params, ", "))
+}
+
+type ProtocolStatement struct {
+	Token   lexer.Token
+	Name    *Identifier
+	Members []*ProtocolMember
+}
+
+func (ps *ProtocolStatement) statementNode() {}
+func (ps *ProtocolStatement) String() string {
+	members := make([]string, 0, len(ps.Members))
+	for _, member := range ps.Members {
+		members = append(members, member.String())
+	}
+	return fmt.Sprintf(
+		"protocol %s {%s}",
+		ps.Name.String(),
+		strings.Join(members, ", "),
+	)
+}
+
+type OperatorStatement struct {
+	Token         lexer.Token
+	Operator      string
+	Precedence    int
+	Associativity string
+	Function      Expression
+}
+
+func (os *OperatorStatement) statementNode() {}
+func (os *OperatorStatement) String() string {
+	return fmt.Sprintf(
+		"operator %s %d %s = %s",
+		os.Operator,
+		os.Precedence,
+		os.Associativity,
+		os.Function.String(),
+	)
+}
+
+type MacroStatement struct {
+	Token      lexer.Token
+	Name       *Identifier
+	Parameters []*Identifier
+	Body       *BlockExpression
+}
+
+func (ms *MacroStatement) statementNode() {}
+func (ms *MacroStatement) String() string {
+	var sb strings.Builder
+	sb.WriteString("macro " + ms.Name.String() + "(")
+	for idx, param := range ms.Parameters {
+		sb.WriteString(param.String())
+		if idx != len(ms.Parameters)-1 {
+			sb.WriteString(", ")
+		}
+	}
+	sb.WriteString(") " + ms.Body.String())
+	return sb.String()
+}
@@ -1,8 +1,8 @@
 package environment
 
 import (
+	"errors"
 	"fmt"
-	"maps"
 )
 
 func (e *Environment) loadBuiltin() {
@@ -35,22 +35,150 @@ func (e *Environment) loadBuiltin() {
 		return NewString(input), nil
 	}))
 
+	// earlier metas win in lookup
 	e.Create("set_meta", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		args[0].(*document).Meta = args[1].(*document)
+		docs, err := documents("set_meta", args)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			return nil, errors.New("set_meta want document")
+		}
+		if err := checkWritable(docs[0]); err != nil {
+			return nil, fmt.Errorf("set_meta: %w", err)
+		}
+		if err := SetMetas(docs[0], docs[1:]); err != nil {
+			return nil, fmt.Errorf("set_meta: %w", err)
+		}
 		return NewNil(), nil
 	}))
 
 	e.Create("get_meta", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		return args[0].(*document).Meta, nil
+		return firstMeta(args[0]), nil
+	}))
+
+	e.Create("isinstance", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		cls, ok := args[1].(*document)
+		if !ok {
+			return nil, fmt.Errorf("isinstance want document class, got %s", args[1].Type())
+		}
+		doc, ok := args[0].(*document)
+		if !ok {
+			return NewBoolean(false), nil
+		}
+		is, err := IsInstance(doc, cls)
+		if err != nil {
+			return nil, fmt.Errorf("isinstance: %w", err)
+		}
+		return NewBoolean(is), nil
+	}))
+
+	e.Create("class_of", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return firstMeta(args[0]), nil
+	}))
+
+	e.Create("bases", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		cls, ok := args[0].(*document)
+		if !ok {
+			return nil, fmt.Errorf("bases want document class, got %s", args[0].Type())
+		}
+		bases := NewDocument()
+		for _, meta := range cls.Metas {
+			bases.List = append(bases.List, meta)
+		}
+		return bases, nil
+	}))
+
+	e.Create("mro", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		cls, ok := args[0].(*document)
+		if !ok {
+			return nil, fmt.Errorf("mro want document class, got %s", args[0].Type())
+		}
+		order, err := MRO(cls)
+		if err != nil {
+			return nil, fmt.Errorf("mro: %w", err)
+		}
+		result := NewDocument()
+		for _, doc := range order {
+			result.List = append(result.List, doc)
+		}
+		return result, nil
+	}))
+
+	e.Create("implements", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		protocol, ok := isProtocol(args[1])
+		if !ok {
+			return nil, fmt.Errorf("implements want protocol, got %s", args[1].Type())
+		}
+		missing, err := MissingMembers(args[0], protocol)
+		if err != nil {
+			return nil, fmt.Errorf("implements: %w", err)
+		}
+		return NewBoolean(len(missing) == 0), nil
+	}))
+
+	// returns cls to wrap class definition
+	e.Create("conform", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		protocol, ok := isProtocol(args[1])
+		if !ok {
+			return nil, fmt.Errorf("conform want protocol, got %s", args[1].Type())
+		}
+		if err := conform(args[0], protocol); err != nil {
+			return nil, err
+		}
+		return args[0], nil
+	}))
+
+	e.Create("freeze", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		doc, ok := args[0].(*document)
+		if !ok {
+			return nil, fmt.Errorf("freeze want document, got %s", args[0].Type())
+		}
+		Freeze(doc)
+		return doc, nil
+	}))
+
+	e.Create("deep_freeze", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		doc, ok := args[0].(*document)
+		if !ok {
+			return nil, fmt.Errorf("deep_freeze want document, got %s", args[0].Type())
+		}
+		DeepFreeze(doc)
+		return doc, nil
+	}))
+
+	// values other than documents can not be changed
+	e.Create("is_frozen", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		if doc, ok := args[0].(*document); ok {
+			return NewBoolean(doc.Frozen), nil
+		}
+		return NewBoolean(true), nil
 	}))
 
 	e.Create("merge", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		left := args[0].(*document)
 		right := args[1].(*document)
-		maps.Copy(left.Attrs, right.Attrs)
+		if err := checkWritable(left); err != nil {
+			return nil, fmt.Errorf("merge: %w", err)
+		}
+		left.Attrs.Merge(right.Attrs)
 		return NewNil(), nil
 	}))
 
+	e.Create("attrs", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		doc, ok := args[0].(*document)
+		if !ok {
+			return nil, fmt.Errorf("attrs want document, got %s", args[0].Type())
+		}
+		dict := newDict(nil)
+		for key, val := range doc.Attrs.All() {
+			if err := dict.Dict.Set(be, NewString(key), val); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}))
+
 	e.Create("str", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s, err := MetaCall(args[0], "__str", be, nil)
 		if err != nil {
@@ -75,6 +203,56 @@ func (e *Environment) loadBuiltin() {
 		return b, nil
 	}))
 
+	// options are attributes indent, depth and width
+	e.Create("pretty", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		opts := DefaultPretty
+		if len(args) > 1 {
+			doc, ok := args[1].(*document)
+			if !ok {
+				return nil, fmt.Errorf("pretty want document options, got %s", args[1].Type())
+			}
+			for _, limit := range []struct {
+				name  string
+				value *int
+			}{
+				{"indent", &opts.Indent},
+				{"depth", &opts.Depth},
+				{"width", &opts.Width},
+			} {
+				val, ok := doc.Attrs.Get(limit.name)
+				if !ok {
+					continue
+				}
+				n, ok := val.(*number)
+				if !ok || n.Value < 0 {
+					return nil, fmt.Errorf("pretty: %s must be non-negative number", limit.name)
+				}
+				*limit.value = int(n.Value)
+			}
+		}
+		result, err := Pretty(be, args[0], opts)
+		if err != nil {
+			return nil, fmt.Errorf("pretty: %w", err)
+		}
+		return NewString(result), nil
+	}))
+
+	e.Create("repr", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		result, err := Repr(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("repr: %w", err)
+		}
+		return NewString(result), nil
+	}))
+
+	e.Create("hash", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		h, err := Hash(be, args[0])
+		if err != nil {
+			return nil, fmt.Errorf("hash: %w", err)
+		}
+		return NewNumber(float64(h)), nil
+	}))
+
 	e.Create("type", NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		return NewString(string(args[0].Type())), nil
 	}))
@@ -98,3 +276,23 @@ func print(be blockEvaluator, self Object, args ...Object) (Object, error) {
 
 	return NewNil(), nil
 }
+
+func documents(name string, args []Object) ([]*document, error) {
+	docs := make([]*document, 0, len(args))
+	for _, arg := range args {
+		doc, ok := arg.(*document)
+		if !ok {
+			return nil, fmt.Errorf("%s want document, got %s", name, arg.Type())
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// main meta of document or nil
+func firstMeta(object Object) Object {
+	if doc, ok := object.(*document); ok && len(doc.Metas) != 0 {
+		return doc.Metas[0]
+	}
+	return NewNil()
+}
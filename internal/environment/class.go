@@ -1,39 +1,71 @@
 package environment
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 )
 
 func NewResult(value Object, ok *boolean) *document {
 	r := NewDocument()
-	r.Attrs["value"] = value
-	r.Attrs["ok"] = ok
+	r.Attrs.Set("value", value)
+	r.Attrs.Set("ok", ok)
 	return r
 }
 
+// error document passed to __exit and produced by panic
+func NewError(message string) *document {
+	e := NewDocument()
+	e.Attrs.Set("message", NewString(message))
+	return e
+}
+
+func NewPairResult(key, value Object, ok *boolean) *document {
+	r := NewResult(value, ok)
+	r.Attrs.Set("key", key)
+	return r
+}
+
+// iterates over List, yields index or element of keys attribute as key
 var iterMeta = func() *document {
 	iter := NewDocument()
-	iter.Attrs["__next"] = NewNativeMethod(func(
+	iter.Attrs.Set("__next", NewNativeMethod(func(
 		be blockEvaluator,
 		self Object,
 		args ...Object,
 	) (Object, error) {
 		s := self.(*document)
-		idx := int(s.Attrs["index"].(*number).Value)
-		s.Attrs["index"] = NewNumber(float64(idx + 1))
+		idx := int(s.Attrs.At("index").(*number).Value)
+		s.Attrs.Set("index", NewNumber(float64(idx+1)))
 		if idx >= len(s.List) {
 			return NewResult(NewNil(), NewBoolean(false)), nil
 		}
-		return NewResult(s.List[idx], NewBoolean(true)), nil
-	})
+		var key Object = NewNumber(float64(idx))
+		if keys, ok := s.Attrs.Get("keys"); ok {
+			key = keys.(*document).List[idx]
+		}
+		return NewPairResult(key, s.List[idx], NewBoolean(true)), nil
+	}))
 	return iter
 }()
 
+func newIter(values []Object, keys []Object) *document {
+	iter := NewDocument()
+	iter.List = values
+	iter.Attrs.Set("index", NewNumber(0))
+	if keys != nil {
+		k := NewDocument()
+		k.List = keys
+		iter.Attrs.Set("keys", k)
+	}
+	iter.Metas = []*document{iterMeta}
+	return iter
+}
+
 func UnpackResult(object Object) (Object, bool, error) {
 	if doc, ok := object.(*document); ok {
-		val, valOk := doc.Attrs["value"]
-		ok, okOk := doc.Attrs["ok"]
+		val, valOk := doc.Attrs.Get("value")
+		ok, okOk := doc.Attrs.Get("ok")
 		okBool, err := CheckBool(ok)
 		if err != nil {
 			return nil, false, err
@@ -45,82 +77,118 @@ func UnpackResult(object Object) (Object, bool, error) {
 	return nil, false, fmt.Errorf("unpack result want document, gor %s", object.Type())
 }
 
+// key is taken from key attribute of result
+// or from value if it is a document with list of two elements
+func UnpackPair(object Object) (Object, Object, bool, error) {
+	val, ok, err := UnpackResult(object)
+	if err != nil || !ok {
+		return nil, nil, ok, err
+	}
+	if key, ok := object.(*document).Attrs.Get("key"); ok {
+		return key, val, true, nil
+	}
+	if pair, ok := val.(*document); ok && len(pair.List) == 2 {
+		return pair.List[0], pair.List[1], true, nil
+	}
+	return nil, nil, false, errors.New("unpack pair want key in result")
+}
+
 func refSelf(self Object) *document {
 	s := self.(*document)
-	if s.Attrs["ref"] != globalNil {
-		s = s.Attrs["ref"].(*document)
+	if s.Attrs.At("ref") != globalNil {
+		s = s.Attrs.At("ref").(*document)
 	}
 	return s
 }
 
-var classList = &document{
-	List: []Object{},
-	Dict: NewDict(),
-	Attrs: map[string]Object{
-		"append": NewNativeMethod(func(
-			be blockEvaluator,
-			self Object,
-			args ...Object,
-		) (Object, error) {
-			s := refSelf(self)
-			s.List = append(s.List, args...)
-			return s, nil
-		}),
-		"reverse": NewNativeMethod(func(
-			be blockEvaluator,
-			self Object,
-			args ...Object,
-		) (Object, error) {
-			s := refSelf(self)
-			slices.Reverse(s.List)
-			return s, nil
-		}),
-		"__iter": NewNativeMethod(func(
-			be blockEvaluator,
-			self Object,
-			args ...Object,
-		) (Object, error) {
-			s := refSelf(self)
+// methods are set in init, they create views themselves
+var (
+	classList = NewDocument()
+	classDict = NewDocument()
+)
 
-			iter := NewDocument()
-			iter.List = s.List
-			iter.Attrs["index"] = NewNumber(0)
-			iter.Meta = iterMeta
-			return iter, nil
-		}),
-	},
-}
+func init() {
+	classList.Attrs.Set("append", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		s := refSelf(self)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		s.List = append(s.List, args...)
+		return s, nil
+	}))
+	classList.Attrs.Set("reverse", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		s := refSelf(self)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		slices.Reverse(s.List)
+		return s, nil
+	}))
+	// list without ref, like slice of whole list
+	classList.Attrs.Set("copy", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		s := refSelf(self)
+		result := newList(nil)
+		result.List = slices.Clone(s.List)
+		return result, nil
+	}))
+	classList.Attrs.Set("__iter", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		s := refSelf(self)
+		return newIter(s.List, nil), nil
+	}))
 
-var classDict = &document{
-	List: []Object{},
-	Dict: NewDict(),
-	Attrs: map[string]Object{
-		"hop": NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-			s := refSelf(self)
-			fmt.Println("HOP!")
-			return s, nil
-		}),
-	},
+	classDict.Attrs.Set("hop", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := refSelf(self)
+		fmt.Println("HOP!")
+		return s, nil
+	}))
+	// dict without ref, entries keep their order
+	classDict.Attrs.Set("copy", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := refSelf(self)
+		result := newDict(nil)
+		result.Dict = s.Dict.Clone()
+		return result, nil
+	}))
+	classDict.Attrs.Set("__iter", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := refSelf(self)
+		keys, values := s.Dict.Items()
+		return newIter(values, keys), nil
+	}))
 }
 
 func newList(ref *document) *document {
 	d := NewDocument()
 	if ref != nil {
-		d.Attrs["ref"] = ref
+		d.Attrs.Set("ref", ref)
 	} else {
-		d.Attrs["ref"] = NewNil()
+		d.Attrs.Set("ref", NewNil())
 	}
-	d.Meta = classList
+	d.Metas = []*document{classList}
 	return d
 }
 
 func newDict(ref *document) *document {
 	d := NewDocument()
 	if ref != nil {
-		d.Attrs["ref"] = ref
+		d.Attrs.Set("ref", ref)
 	} else {
-		d.Attrs["ref"] = NewNil()
+		d.Attrs.Set("ref", NewNil())
 	}
-	d.Meta = classDict
+	d.Metas = []*document{classDict}
 	return d
 }
\ No newline at end of file
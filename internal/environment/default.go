@@ -2,37 +2,147 @@ package environment
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"slices"
 	"strconv"
 	"wildscript/internal/ast"
 )
 
-func LookupAttr(doc *document, attr string) (Object, bool) {
-	if result, ok := doc.Attrs[attr]; ok {
-		return result, ok
+func LookupAttr(doc *document, attr string) (Object, bool, error) {
+	result, owner, err := lookupAttrOwner(doc, attr)
+	return result, owner != nil, err
+}
+
+// attribute with document holding it, nil owner if not found
+func lookupAttrOwner(doc *document, attr string) (Object, *document, error) {
+	order, err := lookupOrder(doc)
+	if err != nil {
+		return nil, nil, err
 	}
-	if doc.Meta != nil {
-		if result, ok := LookupAttr(doc.Meta, attr); ok {
-			return result, ok
+	for _, d := range order {
+		if result, ok := d.Attrs.Get(attr); ok {
+			return result, d, nil
 		}
 	}
-	return nil, false
+	return nil, nil, nil
+}
+
+// __get_<name> or __set_<name> of doc, names of metamethods
+// like __set_index are not accessors of attribute index
+func lookupAccessor(doc *document, prefix string, name string) (Object, bool, error) {
+	if _, ok := defaultMeta[DOCUMENT][prefix+name]; ok {
+		return nil, false, nil
+	}
+	return LookupAttr(doc, prefix+name)
+}
+
+// accessor is called as method of doc
+func callAccessor(be blockEvaluator, accessor Object, doc *document, args ...Object) (Object, error) {
+	f, ok := accessor.(*function)
+	if !ok {
+		return nil, fmt.Errorf("accessor is %s, not a function", accessor.Type())
+	}
+	bound := f.Bind(doc)
+	return bound.Call(be, bound.Bound, args...)
+}
+
+// cls is somewhere in metas of doc
+func IsInstance(doc *document, cls *document) (bool, error) {
+	order, err := lookupOrder(doc)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(order[1:], cls), nil
+}
+
+// other operand type is not handled, operator tries reflected method
+var ErrUnsupported = errors.New("unsupported operand type")
+
+func numOperands(self Object, args []Object) (*number, *number, error) {
+	right, ok := args[0].(*number)
+	if !ok {
+		return nil, nil, ErrUnsupported
+	}
+	return self.(*number), right, nil
+}
+
+func strOperands(self Object, args []Object) (*string_, *string_, error) {
+	right, ok := args[0].(*string_)
+	if !ok {
+		return nil, nil, ErrUnsupported
+	}
+	return self.(*string_), right, nil
+}
+
+func toString(be blockEvaluator, object Object) (*string_, error) {
+	str, err := MetaCall(object, "__str", be, nil)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := str.(*string_)
+	if !ok {
+		return nil, fmt.Errorf("__str returned %s", str.Type())
+	}
+	return result, nil
+}
+
+// other operand of string + is converted by __str,
+// set in init because __str is found through defaultMeta
+func init() {
+	strMeta["__add"] = NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		right, err := toString(be, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewString(self.(*string_).Value + right.Value), nil
+	})
+	strMeta["__radd"] = NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		left, err := toString(be, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewString(left.Value + self.(*string_).Value), nil
+	})
 }
 
 type Callable interface {
 	Call(be blockEvaluator, self Object, args ...Object) (Object, error)
 }
 
-var defaultMeta = map[ObjectType]map[string]*function{
-	STRING:   strMeta,
-	BOOLEAN:  boolMeta,
-	NUMBER:   numMeta,
-	DOCUMENT: docMeta,
-	FUNCTION: funcMeta,
+// filled in init, metamethods of builtin types call MetaCall themselves
+var defaultMeta map[ObjectType]map[string]*function
+
+func init() {
+	defaultMeta = map[ObjectType]map[string]*function{
+		STRING:   strMeta,
+		BOOLEAN:  boolMeta,
+		NUMBER:   numMeta,
+		DOCUMENT: docMeta,
+		FUNCTION: funcMeta,
+		NIL:      nilMeta,
+	}
+}
+
+var nilMeta = map[string]*function{
+	"__hash": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewNumber(0), nil
+	}),
+	"__str": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewString("nil"), nil
+	}),
+	"__bool": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewBoolean(false), nil
+	}),
 }
 
 var boolMeta = map[string]*function{
+	"__hash": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		if self.(*boolean).Value {
+			return NewNumber(1), nil
+		}
+		return NewNumber(2), nil
+	}),
 	"__not": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		if self.(*boolean).Value {
 			return NewBoolean(false), nil
@@ -40,7 +150,11 @@ var boolMeta = map[string]*function{
 		return NewBoolean(true), nil
 	}),
 	"__eq": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*boolean), args[0].(*boolean)
+		left := self.(*boolean)
+		right, ok := args[0].(*boolean)
+		if !ok {
+			return nil, ErrUnsupported
+		}
 		if left.Value != right.Value {
 			return NewBoolean(false), nil
 		}
@@ -54,24 +168,37 @@ var boolMeta = map[string]*function{
 	}),
 }
 
+// first parameter of function taken from document
+var receiverName = map[ast.FunctionImplementation]string{
+	ast.METHOD: "self",
+	ast.STATIC: "class",
+}
+
 var funcMeta = map[string]*function{
 	"__call": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*function)
-		if s.Impl == ast.METHOD {
+		if s.Bound != nil {
+			return s.Call(be, s.Bound, args...)
+		}
+		switch s.Impl {
+		case ast.METHOD, ast.STATIC:
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s called without %s", s.Impl, receiverName[s.Impl])
+			}
 			self = args[0]
 			args = args[1:]
 		}
 		return s.Call(be, self, args...)
 	}),
 	"__str": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		return NewString(string(self.(*function).Impl)), nil
+		return NewString(self.(*function).kind()), nil
 	}),
 }
 
 var docMeta = map[string]*function{
 	"__len": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		val := len(s.Attrs) + len(s.List) + s.Dict.Len()
+		val := s.Attrs.Len() + len(s.List) + s.Dict.Len()
 		return NewNumber(float64(val)), nil
 	}),
 	"__str": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
@@ -79,24 +206,27 @@ var docMeta = map[string]*function{
 	}),
 	"__bool": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		if s.Dict.Len() == 0 && len(s.List) == 0 && len(s.Attrs) == 0 {
+		if s.Dict.Len() == 0 && len(s.List) == 0 && s.Attrs.Len() == 0 {
 			return NewBoolean(false), nil
 		}
 		return NewBoolean(true), nil
 	}),
 	"__index": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		idx := int(args[0].(*number).Value)
-		if idx >= len(s.List) || idx < 0 {
-			return nil, errors.New("index out of range")
+		idx, err := normalizeIndex(args[0], len(s.List))
+		if err != nil {
+			return nil, err
 		}
 		return s.List[idx], nil
 	}),
 	"__set_index": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		idx := int(args[0].(*number).Value)
-		if idx >= len(s.List) || idx < 0 {
-			return nil, errors.New("index out of range")
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		idx, err := normalizeIndex(args[0], len(s.List))
+		if err != nil {
+			return nil, err
 		}
 		s.List[idx] = args[1]
 		return self, nil
@@ -108,12 +238,18 @@ var docMeta = map[string]*function{
 	}),
 	"__set_list": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
 		s.List = slices.Clone(args[0].(*document).List)
 		return s, nil
 	}),
 	"__key": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		result, ok := s.Dict.Get(args[0])
+		result, ok, err := s.Dict.Get(be, args[0])
+		if err != nil {
+			return nil, err
+		}
 		if !ok {
 			return nil, errors.New("key not exists")
 		}
@@ -121,7 +257,12 @@ var docMeta = map[string]*function{
 	}),
 	"__set_key": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		s.Dict.Set(args[0], args[1])
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		if err := s.Dict.Set(be, args[0], args[1]); err != nil {
+			return nil, err
+		}
 		return self, nil
 	}),
 	"__dict": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
@@ -131,110 +272,214 @@ var docMeta = map[string]*function{
 	}),
 	"__set_dict": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
 		dict := args[0].(*document)
 		s.Dict = dict.Dict.Clone()
 		return self, nil
 	}),
+	// __get_<name> goes before stored value,
+	// __missing_attribute is called when nothing is found
 	"__attribute": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
 		prop := args[0].(*string_)
-		if result, ok := LookupAttr(s, prop.Value); ok {
+		getter, ok, err := lookupAccessor(s, "__get_", prop.Value)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return callAccessor(be, getter, s)
+		}
+		result, owner, err := lookupAttrOwner(s, prop.Value)
+		if err != nil {
+			return nil, err
+		}
+		if owner != nil {
+			if method, ok := result.(*function); ok {
+				switch method.Impl {
+				case ast.METHOD:
+					return method.Bind(s), nil
+				case ast.STATIC: // class holding it, not the instance
+					return method.Bind(owner), nil
+				}
+			}
 			return result, nil
 		}
+		missing, ok, err := LookupAttr(s, "__missing_attribute")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return callAccessor(be, missing, s, prop)
+		}
 		return nil, errors.New("attribute not exists")
 	}),
+	// __set_<name> goes before storing value
 	"__set_attribute": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		prop := args[0].(*string_)
+		setter, ok, err := lookupAccessor(s, "__set_", prop.Value)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if _, err := callAccessor(be, setter, s, args[1]); err != nil {
+				return nil, err
+			}
+			return self, nil
+		}
+		s.Attrs.Set(prop.Value, args[1])
+		return self, nil
+	}),
+	"__del_attribute": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
 		prop := args[0].(*string_)
-		s.Attrs[prop.Value] = args[1]
+		if _, ok := s.Attrs.Get(prop.Value); !ok {
+			return nil, errors.New("attribute not exists")
+		}
+		s.Attrs.Delete(prop.Value)
 		return self, nil
 	}),
-	"__slice": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+	"__del_key": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		var start, end int
-		if _, ok := args[0].(*nil_); ok {
-			start = 0
-		} else {
-			start = int(args[0].(*number).Value)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		ok, err := s.Dict.Delete(be, args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("key not exists")
 		}
-		if _, ok := args[1].(*nil_); ok {
-			end = len(s.List)
-		} else {
-			end = int(args[1].(*number).Value)
+		return self, nil
+	}),
+	"__del_index": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		idx, err := normalizeIndex(args[0], len(s.List))
+		if err != nil {
+			return nil, err
+		}
+		s.List = slices.Delete(s.List, idx, idx+1)
+		return self, nil
+	}),
+	"__del_slice": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := self.(*document)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		list, err := deleteSliceList(s.List, args[0], args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		s.List = list
+		return self, nil
+	}),
+	"__slice": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := self.(*document)
+		list, err := sliceList(s.List, args[0], args[1], args[2])
+		if err != nil {
+			return nil, err
 		}
 		slice := newList(nil)
-		slice.List = slices.Clone(s.List[start:end])
+		slice.List = list
 		return slice, nil
 	}),
 	"__set_slice": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		s := self.(*document)
-		var start, end int
-		if _, ok := args[0].(*nil_); ok {
-			start = 0
-		} else {
-			start = int(args[0].(*number).Value)
-		}
-		if _, ok := args[1].(*nil_); ok {
-			end = len(s.List)
-		} else {
-			end = int(args[1].(*number).Value)
-		}
-		list := args[2].(*document).List
-		list = append(list, s.List[end:]...)
-		list = append(s.List[:start], list...)
-		s.List = slices.Clone(list)
+		if err := checkWritable(s); err != nil {
+			return nil, err
+		}
+		values, ok := args[3].(*document)
+		if !ok {
+			return nil, fmt.Errorf("slice assign want document, got %s", args[3].Type())
+		}
+		list, err := setSliceList(s.List, args[0], args[1], args[2], values.List)
+		if err != nil {
+			return nil, err
+		}
+		s.List = list
 		return self, nil
 	}),
 }
 
 var numMeta = map[string]*function{
+	"__hash": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewNumber(float64(hashNumber(self.(*number).Value))), nil
+	}),
 	"__unm": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		return NewNumber(-self.(*number).Value), nil
 	}),
 	"__add": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewNumber(left.Value + right.Value), nil
 	}),
 	"__sub": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewNumber(left.Value - right.Value), nil
 	}),
 	"__mul": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewNumber(left.Value * right.Value), nil
 	}),
 	"__div": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if right.Value == 0 {
 			return nil, errors.New("division by zero")
 		}
 		return NewNumber(left.Value / right.Value), nil
 	}),
 	"__floor_div": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if right.Value == 0 {
 			return nil, errors.New("division by zero")
 		}
 		return NewNumber(math.Floor(left.Value / right.Value)), nil
 	}),
 	"__mod": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if right.Value == 0 {
 			return nil, errors.New("modulo by zero")
 		}
 		return NewNumber(math.Mod(left.Value, right.Value)), nil
 	}),
 	"__pow": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewNumber(math.Pow(left.Value, right.Value)), nil
 	}),
 	"__str": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		return NewString(
-			strconv.FormatFloat(
-				self.(*number).Value,
-				'g', -1, 64,
-			),
-		), nil
+		return NewString(formatNumber(self.(*number).Value)), nil
 	}),
 	"__bool": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		if self.(*number).Value != 0 {
@@ -243,42 +488,60 @@ var numMeta = map[string]*function{
 		return NewBoolean(false), nil
 	}),
 	"__eq": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value == right.Value {
 			return NewBoolean(true), nil
 		}
 		return NewBoolean(false), nil
 	}),
 	"__ne": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value != right.Value {
 			return NewBoolean(true), nil
 		}
 		return NewBoolean(false), nil
 	}),
 	"__lt": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value < right.Value {
 			return NewBoolean(true), nil
 		}
 		return NewBoolean(false), nil
 	}),
 	"__le": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value <= right.Value {
 			return NewBoolean(true), nil
 		}
 		return NewBoolean(false), nil
 	}),
 	"__gt": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value > right.Value {
 			return NewBoolean(true), nil
 		}
 		return NewBoolean(false), nil
 	}),
 	"__ge": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*number), args[0].(*number)
+		left, right, err := numOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		if left.Value >= right.Value {
 			return NewBoolean(true), nil
 		}
@@ -287,19 +550,24 @@ var numMeta = map[string]*function{
 }
 
 var strMeta = map[string]*function{
+	"__hash": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewNumber(float64(hashString(self.(*string_).Value))), nil
+	}),
 	"__str": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		return self, nil
 	}),
-	"__add": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*string_), args[0].(*string_)
-		return NewString(left.Value + right.Value), nil
-	}),
 	"__eq": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*string_), args[0].(*string_)
+		left, right, err := strOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewBoolean(left.Value == right.Value), nil
 	}),
 	"__ne": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
-		left, right := self.(*string_), args[0].(*string_)
+		left, right, err := strOperands(self, args)
+		if err != nil {
+			return nil, err
+		}
 		return NewBoolean(left.Value != right.Value), nil
 	}),
 	"__len": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
@@ -307,29 +575,23 @@ var strMeta = map[string]*function{
 	}),
 	"__index": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		sl := []rune(self.(*string_).Value)
-		idx := int(args[0].(*number).Value)
-		if idx >= len(sl) || idx < 0 {
-			return nil, errors.New("index out of range")
+		idx, err := normalizeIndex(args[0], len(sl))
+		if err != nil {
+			return nil, err
 		}
 		return NewString(string(sl[idx])), nil
 	}),
 	"__slice": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		sl := []rune(self.(*string_).Value)
-		var start, end int
-		if _, ok := args[0].(*nil_); ok {
-			start = 0
-		} else {
-			start = int(args[0].(*number).Value)
-		}
-		if _, ok := args[1].(*nil_); ok {
-			end = len(sl)
-		} else {
-			end = int(args[1].(*number).Value)
+		indices, err := sliceIndices(args[0], args[1], args[2], len(sl))
+		if err != nil {
+			return nil, err
 		}
-		if start < 0 || end > len(sl) {
-			return nil, errors.New("index out of range")
+		result := make([]rune, 0, len(indices))
+		for _, idx := range indices {
+			result = append(result, sl[idx])
 		}
-		return NewString(string(sl[start:end])), nil
+		return NewString(string(result)), nil
 	}),
 	"__num": NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
 		result, err := strconv.ParseFloat(self.(*string_).Value, 64)
@@ -1,65 +1,147 @@
 package environment
 
 import (
-	"fmt"
-	"maps"
-	"strings"
+	"container/list"
+	"errors"
 )
 
+type entry struct {
+	key   Object
+	value Object
+}
+
+// keys are grouped by __hash, collisions are resolved by ==,
+// entries are kept in insertion order
 type Dict struct {
-	strMap map[string]Object
-	numMap map[float64]Object
+	buckets map[uint64][]*list.Element
+	order   *list.List
 }
 
 func (d *Dict) Len() int {
-	return len(d.numMap) + len(d.strMap)
+	return d.order.Len()
 }
 
 func NewDict() *Dict {
 	return &Dict{
-		strMap: map[string]Object{},
-		numMap: map[float64]Object{},
+		buckets: map[uint64][]*list.Element{},
+		order:   list.New(),
 	}
 }
 
 func (d *Dict) Clone() *Dict {
-	return &Dict{
-		strMap: maps.Clone(d.strMap),
-		numMap: maps.Clone(d.numMap),
+	clone := NewDict()
+	index := map[*list.Element]*list.Element{}
+	for elem := d.order.Front(); elem != nil; elem = elem.Next() {
+		e := *elem.Value.(*entry)
+		index[elem] = clone.order.PushBack(&e)
+	}
+	for hash, bucket := range d.buckets {
+		cloned := make([]*list.Element, len(bucket))
+		for idx, elem := range bucket {
+			cloned[idx] = index[elem]
+		}
+		clone.buckets[hash] = cloned
+	}
+	return clone
+}
+
+func (d *Dict) Items() ([]Object, []Object) {
+	keys := make([]Object, 0, d.Len())
+	values := make([]Object, 0, d.Len())
+	for elem := d.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		keys = append(keys, e.key)
+		values = append(values, e.value)
+	}
+	return keys, values
+}
+
+// position of key in its bucket or -1
+func (d *Dict) find(be blockEvaluator, k Object) (uint64, int, error) {
+	hash, err := Hash(be, k)
+	if err != nil {
+		return 0, 0, err
+	}
+	for idx, elem := range d.buckets[hash] {
+		eq, err := Equal(be, elem.Value.(*entry).key, k)
+		if err != nil {
+			return 0, 0, err
+		}
+		if eq {
+			return hash, idx, nil
+		}
 	}
+	return hash, -1, nil
 }
 
-func (d *Dict) String() string {
-	var sb strings.Builder
-	for key, val := range d.strMap {
-		sb.WriteString(fmt.Sprintf("%s: %s, ", key, val.Inspect()))
+func (d *Dict) Set(be blockEvaluator, k, v Object) error {
+	hash, idx, err := d.find(be, k)
+	if err != nil {
+		return err
 	}
-	for key, val := range d.numMap {
-		sb.WriteString(fmt.Sprintf("%f: %s, ", key, val.Inspect()))
+	if idx != -1 {
+		d.buckets[hash][idx].Value.(*entry).value = v
+		return nil
 	}
-	return sb.String()
+	if err := checkKey(k, map[*document]bool{}); err != nil {
+		return err
+	}
+	elem := d.order.PushBack(&entry{key: k, value: v})
+	d.buckets[hash] = append(d.buckets[hash], elem)
+	return nil
+}
+
+func (d *Dict) Get(be blockEvaluator, k Object) (Object, bool, error) {
+	hash, idx, err := d.find(be, k)
+	if err != nil || idx == -1 {
+		return nil, false, err
+	}
+	return d.buckets[hash][idx].Value.(*entry).value, true, nil
 }
 
-func (d *Dict) Set(k, v Object) {
-	switch k := k.(type) {
-	case *number:
-		d.numMap[k.Value] = v
-	case *string_:
-		d.strMap[k.Value] = v
-	default:
-		panic("TODO")
+// reports whether key existed
+func (d *Dict) Delete(be blockEvaluator, k Object) (bool, error) {
+	hash, idx, err := d.find(be, k)
+	if err != nil || idx == -1 {
+		return false, err
+	}
+	bucket := d.buckets[hash]
+	d.order.Remove(bucket[idx])
+	bucket = append(bucket[:idx:idx], bucket[idx+1:]...)
+	if len(bucket) == 0 {
+		delete(d.buckets, hash)
+	} else {
+		d.buckets[hash] = bucket
 	}
+	return true, nil
 }
 
-func (d *Dict) Get(k Object) (Object, bool) {
-	switch k := k.(type) {
-	case *string_:
-		v, ok := d.strMap[k.Value]
-		return v, ok
-	case *number:
-		v, ok := d.numMap[k.Value]
-		return v, ok
-	default:
-		panic("TODO")
+// key hashed by contents must not change after insertion,
+// so it and documents inside it must be frozen
+func checkKey(k Object, visited map[*document]bool) error {
+	doc, ok := k.(*document)
+	if !ok || visited[doc] {
+		return nil
+	}
+	visited[doc] = true
+
+	structural, err := ownMetaMissing("__hash", doc)
+	if err != nil || !structural {
+		return err
+	}
+	if !doc.Frozen {
+		return errors.New("unfrozen document can not be key")
+	}
+
+	values := append([]Object{}, doc.List...)
+	for _, attr := range doc.Attrs.All() {
+		values = append(values, attr)
+	}
+	_, dictValues := doc.Dict.Items()
+	for _, value := range append(values, dictValues...) {
+		if err := checkKey(value, visited); err != nil {
+			return err
+		}
 	}
+	return nil
 }
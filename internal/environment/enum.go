@@ -0,0 +1,58 @@
+package environment
+
+import "fmt"
+
+// enum is a frozen document with members as attributes and as list,
+// every member is a distinct frozen document with name and value
+func NewEnum(name string, names []string, values []Object) (*document, error) {
+	memberMeta := NewDocument()
+	memberMeta.Attrs.Set("__str", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		member := self.(*document).Attrs.At("name").(*string_)
+		return NewString(name + "." + member.Value), nil
+	}))
+	memberMeta.Attrs.Set("__eq", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewBoolean(self == args[0]), nil
+	}))
+	memberMeta.Attrs.Set("__ne", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewBoolean(self != args[0]), nil
+	}))
+	memberMeta.Attrs.Set("__hash", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewNumber(float64(identityHash(self))), nil
+	}))
+	memberMeta.Frozen = true
+
+	enumMeta := NewDocument()
+	enumMeta.Attrs.Set("__str", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewString("enum " + name), nil
+	}))
+	enumMeta.Attrs.Set("__len", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		return NewNumber(float64(len(self.(*document).List))), nil
+	}))
+	enumMeta.Attrs.Set("__iter", NewNativeMethod(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		s := self.(*document)
+		keys := make([]Object, 0, len(s.List))
+		for _, member := range s.List {
+			keys = append(keys, member.(*document).Attrs.At("name"))
+		}
+		return newIter(s.List, keys), nil
+	}))
+	enumMeta.Frozen = true
+
+	enum := NewDocument()
+	enum.Metas = []*document{enumMeta}
+	for idx, memberName := range names {
+		if _, ok := enum.Attrs.Get(memberName); ok {
+			return nil, fmt.Errorf("enum member %s already exists", memberName)
+		}
+		member := NewDocument()
+		member.Attrs.Set("name", NewString(memberName))
+		member.Attrs.Set("value", values[idx])
+		member.Metas = []*document{memberMeta}
+		member.Frozen = true
+
+		enum.Attrs.Set(memberName, member)
+		enum.List = append(enum.List, member)
+	}
+	enum.Frozen = true
+	return enum, nil
+}
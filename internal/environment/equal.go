@@ -0,0 +1,254 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+)
+
+// hashes are numbers, so they keep to exact float integers
+const hashMask = 1<<53 - 1
+
+type pair [2]*document
+
+// == of two objects, documents without own __eq compare structurally,
+// otherwise own __eq is asked first, pair not handled by either side compares identity
+func Equal(be blockEvaluator, a, b Object) (bool, error) {
+	return equal(be, a, b, map[pair]bool{})
+}
+
+func equal(be blockEvaluator, a, b Object, visited map[pair]bool) (bool, error) {
+	da, aok := a.(*document)
+	db, bok := b.(*document)
+	if aok && bok {
+		structural, err := ownMetaMissing("__eq", da, db)
+		if err != nil {
+			return false, err
+		}
+		if structural {
+			return structuralEqual(be, da, db, visited)
+		}
+		// own __eq of right side goes before default __eq of left one
+		if plain, err := ownMetaMissing("__eq", da); err != nil {
+			return false, err
+		} else if plain {
+			a, b = b, a
+		}
+	}
+
+	result, err := MetaCall(a, "__eq", be, nil, b)
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrNoMeta) {
+		result, err = MetaCall(b, "__eq", be, nil, a)
+	}
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrNoMeta) {
+		return a == b, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return CheckBool(result)
+}
+
+// != of two objects, own __ne of either side decides,
+// otherwise it is negated ==, so overriding __eq alone is enough
+func NotEqual(be blockEvaluator, a, b Object) (bool, error) {
+	for _, sides := range [][2]Object{{a, b}, {b, a}} {
+		doc, ok := sides[0].(*document)
+		if !ok {
+			continue
+		}
+		own, err := lookupDocMeta(doc, "__ne")
+		if err != nil {
+			return false, err
+		}
+		if own == nil {
+			continue
+		}
+		result, err := MetaCall(doc, "__ne", be, nil, sides[1])
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return CheckBool(result)
+	}
+	eq, err := Equal(be, a, b)
+	return !eq, err
+}
+
+// same metas, equal lists, dicts and attributes,
+// pair met again inside itself is taken as equal
+func structuralEqual(be blockEvaluator, a, b *document, visited map[pair]bool) (bool, error) {
+	if a == b || visited[pair{a, b}] {
+		return true, nil
+	}
+	visited[pair{a, b}] = true
+
+	if !slices.Equal(a.Metas, b.Metas) ||
+		len(a.List) != len(b.List) ||
+		a.Attrs.Len() != b.Attrs.Len() ||
+		a.Dict.Len() != b.Dict.Len() {
+		return false, nil
+	}
+
+	for idx, val := range a.List {
+		if eq, err := equal(be, val, b.List[idx], visited); err != nil || !eq {
+			return false, err
+		}
+	}
+	for key, val := range a.Attrs.All() {
+		other, ok := b.Attrs.Get(key)
+		if !ok {
+			return false, nil
+		}
+		if eq, err := equal(be, val, other, visited); err != nil || !eq {
+			return false, err
+		}
+	}
+	keys, values := a.Dict.Items()
+	for idx, key := range keys {
+		other, ok, err := b.Dict.Get(be, key)
+		if err != nil || !ok {
+			return false, err
+		}
+		if eq, err := equal(be, values[idx], other, visited); err != nil || !eq {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// __hash of object, documents without own __hash and __eq hash their contents,
+// objects without __hash hash their identity
+func Hash(be blockEvaluator, object Object) (uint64, error) {
+	return hash(be, object, map[*document]bool{})
+}
+
+func hash(be blockEvaluator, object Object, visited map[*document]bool) (uint64, error) {
+	if doc, ok := object.(*document); ok {
+		structural, err := ownMetaMissing("__hash", doc)
+		if err != nil {
+			return 0, err
+		}
+		if structural {
+			// equal documents must have equal hashes
+			plain, err := ownMetaMissing("__eq", doc)
+			if err != nil {
+				return 0, err
+			}
+			if !plain {
+				return 0, errors.New("document with __eq must have __hash")
+			}
+			return structuralHash(be, doc, visited)
+		}
+	}
+
+	result, err := MetaCall(object, "__hash", be, nil)
+	if errors.Is(err, ErrNoMeta) {
+		return identityHash(object), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, ok := result.(*number)
+	if !ok {
+		return 0, fmt.Errorf("__hash returned %s", result.Type())
+	}
+	return uint64(int64(n.Value)) & hashMask, nil
+}
+
+// order of attributes and dict entries does not matter
+func structuralHash(be blockEvaluator, doc *document, visited map[*document]bool) (uint64, error) {
+	if visited[doc] {
+		return 0, nil
+	}
+	visited[doc] = true
+
+	var result uint64 = 17
+	for _, val := range doc.List {
+		h, err := hash(be, val, visited)
+		if err != nil {
+			return 0, err
+		}
+		result = result*31 + h
+	}
+	for key, val := range doc.Attrs.All() {
+		h, err := hash(be, val, visited)
+		if err != nil {
+			return 0, err
+		}
+		result += mix(hashString(key), h)
+	}
+	keys, values := doc.Dict.Items()
+	for idx, key := range keys {
+		hk, err := hash(be, key, visited)
+		if err != nil {
+			return 0, err
+		}
+		hv, err := hash(be, values[idx], visited)
+		if err != nil {
+			return 0, err
+		}
+		result += mix(hk, hv)
+	}
+	return result & hashMask, nil
+}
+
+// none of docs has metamethod name in its metas
+func ownMetaMissing(name string, docs ...*document) (bool, error) {
+	for _, doc := range docs {
+		meta, err := lookupDocMeta(doc, name)
+		if err != nil || meta != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func identityHash(object Object) uint64 {
+	return hashString(fmt.Sprintf("%p", object))
+}
+
+func mix(a, b uint64) uint64 {
+	return (a*0x9e3779b97f4a7c15 ^ b) * 0xbf58476d1ce4e5b9
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64() & hashMask
+}
+
+func hashNumber(value float64) uint64 {
+	if value == 0 {
+		value = 0 // -0
+	}
+	return mix(math.Float64bits(value), 0) & hashMask
+}
+
+// set in init because nested values are compared through defaultMeta
+func init() {
+	docMeta["__eq"] = NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		other, ok := args[0].(*document)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		eq, err := structuralEqual(be, self.(*document), other, map[pair]bool{})
+		return NewBoolean(eq), err
+	})
+	docMeta["__ne"] = NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		other, ok := args[0].(*document)
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		eq, err := structuralEqual(be, self.(*document), other, map[pair]bool{})
+		return NewBoolean(!eq), err
+	})
+	docMeta["__hash"] = NewNative(func(be blockEvaluator, self Object, args ...Object) (Object, error) {
+		h, err := structuralHash(be, self.(*document), map[*document]bool{})
+		return NewNumber(float64(h)), err
+	})
+}
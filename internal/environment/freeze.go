@@ -0,0 +1,45 @@
+package environment
+
+import "errors"
+
+var errFrozen = errors.New("document is frozen")
+
+func checkWritable(doc *document) error {
+	if doc.Frozen {
+		return errFrozen
+	}
+	return nil
+}
+
+// list, dict and attributes of doc become read-only,
+// nested documents stay writable
+func Freeze(doc *document) {
+	doc.Frozen = true
+}
+
+// freezes doc and every document reachable from its contents,
+// metas are not contents and stay writable
+func DeepFreeze(doc *document) {
+	deepFreeze(doc, map[*document]bool{})
+}
+
+func deepFreeze(doc *document, visited map[*document]bool) {
+	if visited[doc] {
+		return
+	}
+	visited[doc] = true
+	doc.Frozen = true
+
+	values := append([]Object{}, doc.List...)
+	keys, dictValues := doc.Dict.Items()
+	values = append(values, keys...)
+	values = append(values, dictValues...)
+	for _, attr := range doc.Attrs.All() {
+		values = append(values, attr)
+	}
+	for _, value := range values {
+		if d, ok := value.(*document); ok {
+			deepFreeze(d, visited)
+		}
+	}
+}
@@ -3,8 +3,6 @@ package environment
 import (
 	"fmt"
 	"wildscript/internal/ast"
-
-	"github.com/fatih/color"
 )
 
 type Native func(
@@ -19,6 +17,8 @@ type function struct {
 	Environment *Environment
 	Native      Native
 	Impl        ast.FunctionImplementation
+	Bound       Object      // receiver of method taken from document
+	Pragmas     ast.Pragmas // of module where function is defined
 }
 
 func NewNative(f Native) *function {
@@ -38,22 +38,41 @@ func NewFunction(params []*ast.Identifier,
 	body *ast.BlockExpression,
 	env *Environment,
 	Impl ast.FunctionImplementation,
+	pragmas ast.Pragmas,
 ) *function {
 	return &function{
 		Parameters:  params,
 		Body:        body,
 		Environment: env,
 		Impl:        Impl,
+		Pragmas:     pragmas,
+	}
+}
+
+// copy of method with receiver, bound method stays bound to first receiver
+func (f *function) Bind(self Object) *function {
+	if f.Bound != nil {
+		return f
 	}
+	bound := *f
+	bound.Bound = self
+	return &bound
 }
 
 func (f *function) Type() ObjectType { return FUNCTION }
 func (f *function) Inspect() string {
+	return fmt.Sprintf("function<%s>", f.kind())
+}
+
+func (f *function) kind() string {
+	kind := string(f.Impl)
 	if f.Native != nil {
-		return color.MagentaString("function<native>")
+		kind = "native"
+	}
+	if f.Bound != nil {
+		kind = "bound " + kind
 	}
-	return color.MagentaString(
-		fmt.Sprintf("function<%s>", f.Impl))
+	return kind
 }
 
 type blockEvaluator interface {
@@ -61,6 +80,7 @@ type blockEvaluator interface {
 		block *ast.BlockExpression,
 		outer *Environment,
 		args map[string]Object,
+		pragmas ast.Pragmas,
 	) Object
 }
 
@@ -73,7 +93,7 @@ func (f *function) Call(
 		return f.Native(be, self, args...)
 	}
 
-	if f.Impl == ast.METHOD {
+	if f.Impl == ast.METHOD || f.Impl == ast.STATIC {
 		args = append([]Object{self}, args...)
 	}
 
@@ -90,7 +110,8 @@ func (f *function) Call(
 		fArgs[f.Parameters[idx].Value] = arg
 	}
 
-	result := be.EvalBlock(f.Body, f.Environment, fArgs)
+	// pragmas are lexical, body runs with pragmas of its module
+	result := be.EvalBlock(f.Body, f.Environment, fArgs, f.Pragmas)
 
 	if result.Type() == SIGNAL {
 		if ret, ok := result.(*Return); ok {
@@ -0,0 +1,125 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+)
+
+// negative index counts from the end
+func normalizeIndex(index Object, length int) (int, error) {
+	idx := int(index.(*number).Value)
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 || idx >= length {
+		return 0, errors.New("index out of range")
+	}
+	return idx, nil
+}
+
+// python style slice bounds, omitted bounds are nil, bounds are clamped
+func sliceRange(start, end, step Object, length int) (int, int, int, error) {
+	st := 1
+	if n, ok := step.(*number); ok {
+		st = int(n.Value)
+	}
+	if st == 0 {
+		return 0, 0, 0, errors.New("slice step cannot be zero")
+	}
+
+	lower, upper := 0, length
+	if st < 0 {
+		lower, upper = -1, length-1
+	}
+	bound := func(object Object, def int) int {
+		n, ok := object.(*number)
+		if !ok {
+			return def
+		}
+		idx := int(n.Value)
+		if idx < 0 {
+			idx += length
+		}
+		return max(lower, min(idx, upper))
+	}
+
+	if st > 0 {
+		return bound(start, lower), bound(end, upper), st, nil
+	}
+	return bound(start, upper), bound(end, lower), st, nil
+}
+
+func sliceIndices(start, end, step Object, length int) ([]int, error) {
+	from, to, st, err := sliceRange(start, end, step, length)
+	if err != nil {
+		return nil, err
+	}
+	indices := []int{}
+	for idx := from; (st > 0 && idx < to) || (st < 0 && idx > to); idx += st {
+		indices = append(indices, idx)
+	}
+	return indices, nil
+}
+
+func sliceList(list []Object, start, end, step Object) ([]Object, error) {
+	indices, err := sliceIndices(start, end, step, len(list))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Object, 0, len(indices))
+	for _, idx := range indices {
+		result = append(result, list[idx])
+	}
+	return result, nil
+}
+
+// returns new list, step 1 replaces range, others replace element by element
+func setSliceList(list []Object, start, end, step Object, values []Object) ([]Object, error) {
+	from, to, st, err := sliceRange(start, end, step, len(list))
+	if err != nil {
+		return nil, err
+	}
+
+	if st == 1 {
+		to = max(from, to)
+		result := make([]Object, 0, len(list)-(to-from)+len(values))
+		result = append(result, list[:from]...)
+		result = append(result, values...)
+		result = append(result, list[to:]...)
+		return result, nil
+	}
+
+	indices, _ := sliceIndices(start, end, step, len(list))
+	if len(indices) != len(values) {
+		return nil, fmt.Errorf(
+			"assign %d element(s) to extended slice of %d",
+			len(values),
+			len(indices),
+		)
+	}
+	result := make([]Object, len(list))
+	copy(result, list)
+	for idx, val := range values {
+		result[indices[idx]] = val
+	}
+	return result, nil
+}
+
+// returns new list without elements of slice
+func deleteSliceList(list []Object, start, end, step Object) ([]Object, error) {
+	indices, err := sliceIndices(start, end, step, len(list))
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[int]bool, len(indices))
+	for _, idx := range indices {
+		deleted[idx] = true
+	}
+	result := make([]Object, 0, len(list)-len(indices))
+	for idx, val := range list {
+		if !deleted[idx] {
+			result = append(result, val)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package environment
+
+import (
+	"errors"
+	"slices"
+)
+
+// c3 linearization of doc and its metas, doc goes first,
+// every meta goes after all documents that list it
+func MRO(doc *document) ([]*document, error) {
+	return linearize(doc, map[*document]bool{})
+}
+
+// cached MRO of doc, metas of parent can change after set_meta of doc,
+// so inconsistent order is reported here too
+func lookupOrder(doc *document) ([]*document, error) {
+	if len(doc.Metas) == 0 {
+		return []*document{doc}, nil
+	}
+	if !doc.order.fresh() {
+		order, err := MRO(doc)
+		doc.order = mroCache{order, err, metaVersions(doc, map[*document]uint64{})}
+	}
+	return doc.order.order, doc.order.err
+}
+
+// versions of doc and every document reachable through its metas
+func metaVersions(doc *document, versions map[*document]uint64) map[*document]uint64 {
+	if _, ok := versions[doc]; ok {
+		return versions
+	}
+	versions[doc] = doc.metaVersion
+	for _, meta := range doc.Metas {
+		metaVersions(meta, versions)
+	}
+	return versions
+}
+
+func linearize(doc *document, visiting map[*document]bool) ([]*document, error) {
+	if visiting[doc] {
+		return nil, errors.New("meta cycle")
+	}
+	visiting[doc] = true
+	defer delete(visiting, doc)
+
+	seqs := [][]*document{}
+	for _, meta := range doc.Metas {
+		seq, err := linearize(meta, visiting)
+		if err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, seq)
+	}
+	seqs = append(seqs, slices.Clone(doc.Metas))
+
+	result := []*document{doc}
+	for {
+		seqs = slices.DeleteFunc(seqs, func(seq []*document) bool {
+			return len(seq) == 0
+		})
+		if len(seqs) == 0 {
+			return result, nil
+		}
+
+		var head *document
+		for _, seq := range seqs {
+			if !inTails(seq[0], seqs) {
+				head = seq[0]
+				break
+			}
+		}
+		if head == nil {
+			return nil, errors.New("inconsistent meta order")
+		}
+
+		result = append(result, head)
+		for idx, seq := range seqs {
+			if seq[0] == head {
+				seqs[idx] = seq[1:]
+			}
+		}
+	}
+}
+
+type mroCache struct {
+	order []*document
+	err   error
+	// order is stale when metas of any of these documents are set again
+	versions map[*document]uint64
+}
+
+func (c *mroCache) fresh() bool {
+	if c.versions == nil {
+		return false
+	}
+	for doc, version := range c.versions {
+		if doc.metaVersion != version {
+			return false
+		}
+	}
+	return true
+}
+
+func inTails(doc *document, seqs [][]*document) bool {
+	for _, seq := range seqs {
+		if slices.Contains(seq[1:], doc) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaces metas of doc if resulting order is consistent
+func SetMetas(doc *document, metas []*document) error {
+	old := doc.Metas
+	doc.Metas = metas
+	if _, err := MRO(doc); err != nil {
+		doc.Metas = old
+		return err
+	}
+	doc.metaVersion++
+	return nil
+}
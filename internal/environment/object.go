@@ -1,11 +1,11 @@
 package environment
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
-	"strings"
-
-	"github.com/fatih/color"
+	"wildscript/internal/lib"
 )
 
 type ObjectType string
@@ -34,16 +34,34 @@ func CheckBool(b Object) (bool, error) {
 	return false, fmt.Errorf("not bool value %s", b.Type())
 }
 
-func lookupDocMeta(doc *document, metaName string) (Object) {
-	if result, ok := doc.Attrs[metaName]; ok {
-		return result
+// meta method in metas of doc, doc itself is skipped
+func lookupDocMeta(doc *document, metaName string) (Object, error) {
+	order, err := lookupOrder(doc)
+	if err != nil {
+		return nil, err
 	}
-	if doc.Meta != nil {
-		if result := lookupDocMeta(doc.Meta, metaName); result != nil {
-			return result
+	for _, meta := range order[1:] {
+		if result, ok := meta.Attrs.Get(metaName); ok {
+			return result, nil
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+// meta method is not defined for object
+var ErrNoMeta = errors.New("no meta method")
+
+type noMetaError struct {
+	name       string
+	objectType ObjectType
+}
+
+func (e *noMetaError) Error() string {
+	return fmt.Sprintf("no %s method in %s", e.name, e.objectType)
+}
+
+func (e *noMetaError) Is(target error) bool {
+	return target == ErrNoMeta
 }
 
 func MetaCall(
@@ -55,19 +73,17 @@ func MetaCall(
 ) (Object, error) {
 	var metaFunc Object
 	if doc, ok := object.(*document); ok {
-		if doc.Meta != nil {
-			metaFunc = lookupDocMeta(doc.Meta, metaName)
+		result, err := lookupDocMeta(doc, metaName)
+		if err != nil {
+			return nil, err
 		}
+		metaFunc = result
 	}
 
 	if metaFunc == nil {
-		typeMetaFuncs, ok := defaultMeta[object.Type()]
-		if !ok {
-			return nil, fmt.Errorf("type %s default meta not supported", object.Type())
-		}
-		result, ok := typeMetaFuncs[metaName]
+		result, ok := defaultMeta[object.Type()][metaName]
 		if !ok {
-			return nil, fmt.Errorf("no %s method in %s", metaName, object.Type())
+			return nil, &noMetaError{name: metaName, objectType: object.Type()}
 		}
 		metaFunc = result
 	}
@@ -107,9 +123,15 @@ func NewNumber(value float64) *number {
 
 func (f *number) Type() ObjectType { return NUMBER }
 func (f *number) Inspect() string {
-	return color.GreenString(
-		strconv.FormatFloat(f.Value, 'g', -1, 64),
-	)
+	return formatNumber(f.Value)
+}
+
+// integral values below 1e21 are written without exponent
+func formatNumber(value float64) string {
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
 }
 
 type string_ struct {
@@ -138,9 +160,7 @@ func NewBoolean(value bool) *boolean {
 
 func (b *boolean) Type() ObjectType { return BOOLEAN }
 func (b *boolean) Inspect() string {
-	return color.MagentaString(
-		strconv.FormatBool(b.Value),
-	)
+	return strconv.FormatBool(b.Value)
 }
 
 type nil_ struct{}
@@ -151,40 +171,37 @@ func NewNil() *nil_ {
 
 func (n *nil_) Type() ObjectType { return NIL }
 func (n *nil_) Inspect() string {
-	return color.BlueString("nil")
+	return "nil"
 }
 
 type document struct {
 	List  []Object
 	Dict  *Dict
-	Attrs map[string]Object
-	Meta  *document
+	Attrs *lib.OrderedMap[string, Object]
+	Metas []*document
+
+	Frozen bool // rejects writes
+
+	order       mroCache
+	metaVersion uint64 // bumped by SetMetas
+
 }
 
 func NewDocument() *document {
 	return &document{
-		Attrs: make(map[string]Object),
+		Attrs: lib.NewOrderedMap[string, Object](),
 		Dict:  NewDict(),
-		Meta:  nil,
 	}
 }
 
 func (d *document) Type() ObjectType { return DOCUMENT }
+
+// one line, nested documents without evaluator
 func (d *document) Inspect() string {
-	var sb strings.Builder
-	sb.WriteString("{")
-	for key, elem := range d.Attrs {
-		sb.WriteString(fmt.Sprintf("%s = %s, ", key, elem.Inspect()))
-	}
-	sb.WriteString(d.Dict.String())
-	for idx, elem := range d.List {
-		sb.WriteString(fmt.Sprintf("[%d]: %s, ", idx, elem.Inspect()))
-	}
-	result := sb.String()
-	if len(result) > 1 {
-		result = result[:len(result)-2]
+	result, err := Pretty(nil, d, PrettyOptions{})
+	if err != nil {
+		return "{...}"
 	}
-	result += "}"
 	return result
 }
 
@@ -0,0 +1,186 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"wildscript/internal/lexer"
+)
+
+// limits of pretty printer, zero means no limit
+type PrettyOptions struct {
+	Indent int // spaces per nesting level
+	Depth  int // deeper documents are shown as {...}
+	Width  int // document is split into lines when wider
+}
+
+var DefaultPretty = PrettyOptions{Indent: 4, Width: 80}
+
+type printer struct {
+	be   blockEvaluator // nil skips user __str
+	opts PrettyOptions
+	repr bool
+	path map[*document]bool // documents being printed
+}
+
+// documents with own __str are printed by it,
+// document inside itself is printed as <cycle>
+func Pretty(be blockEvaluator, object Object, opts PrettyOptions) (string, error) {
+	p := &printer{be: be, opts: opts, path: map[*document]bool{}}
+	return p.render(object, 0, 0)
+}
+
+// literal read back by parser as equal value,
+// metas are not part of literal
+func Repr(object Object) (string, error) {
+	p := &printer{repr: true, path: map[*document]bool{}}
+	return p.render(object, 0, 0)
+}
+
+func (p *printer) render(object Object, depth, column int) (string, error) {
+	switch object := object.(type) {
+	case *number:
+		if p.repr && (math.IsNaN(object.Value) || math.IsInf(object.Value, 0)) {
+			return "", fmt.Errorf("can not represent %v", object.Value)
+		}
+		return formatNumber(object.Value), nil
+	case *string_:
+		return quote(object.Value), nil
+	case *boolean:
+		return strconv.FormatBool(object.Value), nil
+	case *nil_:
+		return "nil", nil
+	case *document:
+		return p.renderDocument(object, depth, column)
+	}
+	if p.repr {
+		return "", fmt.Errorf("can not represent %s", object.Type())
+	}
+	return object.Inspect(), nil
+}
+
+func (p *printer) renderDocument(doc *document, depth, column int) (string, error) {
+	if p.path[doc] {
+		if p.repr {
+			return "", errors.New("can not represent cyclic document")
+		}
+		return "<cycle>", nil
+	}
+	if !p.repr && p.be != nil {
+		own, err := lookupDocMeta(doc, "__str")
+		if err != nil {
+			return "", err
+		}
+		if own != nil {
+			str, err := toString(p.be, doc)
+			if err != nil {
+				return "", err
+			}
+			return str.Value, nil
+		}
+	}
+	if p.opts.Depth > 0 && depth >= p.opts.Depth {
+		return "{...}", nil
+	}
+
+	p.path[doc] = true
+	defer delete(p.path, doc)
+
+	inner := (depth + 1) * p.opts.Indent
+	parts := []string{}
+	part := func(prefix string, value Object) error {
+		val, err := p.render(value, depth+1, inner+utf8.RuneCountInString(prefix))
+		if err != nil {
+			return err
+		}
+		parts = append(parts, prefix+val)
+		return nil
+	}
+
+	for _, val := range doc.List {
+		if err := part("", val); err != nil {
+			return "", err
+		}
+	}
+	for name, val := range doc.Attrs.All() {
+		if p.repr && !lexer.IsIdentifier(name) {
+			return "", fmt.Errorf("can not represent attribute %q", name)
+		}
+		if err := part(name+" = ", val); err != nil {
+			return "", err
+		}
+	}
+	keys, values := doc.Dict.Items()
+	for idx, key := range keys {
+		k, err := p.render(key, depth+1, inner)
+		if err != nil {
+			return "", err
+		}
+		if err := part(k+": ", values[idx]); err != nil {
+			return "", err
+		}
+	}
+
+	line := "{" + strings.Join(parts, ", ") + "}"
+	if p.repr || len(parts) == 0 || p.fits(line, column) {
+		return line, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for idx, part := range parts {
+		sb.WriteString(strings.Repeat(" ", inner))
+		sb.WriteString(part)
+		if idx != len(parts)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(strings.Repeat(" ", depth*p.opts.Indent))
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+func (p *printer) fits(line string, column int) bool {
+	if strings.Contains(line, "\n") {
+		return false
+	}
+	return p.opts.Width <= 0 || column+utf8.RuneCountInString(line) <= p.opts.Width
+}
+
+// string literal with escapes known to lexer
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteString(`"`)
+	for _, c := range s {
+		switch c {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case 0:
+			sb.WriteString(`\0`)
+		default:
+			switch {
+			case unicode.IsPrint(c):
+				sb.WriteRune(c)
+			case c < utf8.RuneSelf:
+				sb.WriteString(fmt.Sprintf(`\x%02X`, c))
+			default:
+				sb.WriteString(fmt.Sprintf(`\u{%X}`, c))
+			}
+		}
+	}
+	sb.WriteString(`"`)
+	return sb.String()
+}
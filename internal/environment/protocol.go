@@ -0,0 +1,106 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// protocol member, arity is nil for plain attribute
+type ProtocolMember struct {
+	Name  string
+	Arity Object
+}
+
+var protocolMeta = func() *document {
+	protocol := NewDocument()
+	protocol.Attrs.Set("__str", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		return NewString("protocol " + self.(*document).Attrs.At("name").(*string_).Value), nil
+	}))
+	return protocol
+}()
+
+// protocol lists members as documents with name and arity
+func NewProtocol(name string, members []ProtocolMember) *document {
+	protocol := NewDocument()
+	protocol.Attrs.Set("name", NewString(name))
+	for _, member := range members {
+		m := NewDocument()
+		m.Attrs.Set("name", NewString(member.Name))
+		m.Attrs.Set("arity", member.Arity)
+		protocol.List = append(protocol.List, m)
+	}
+	protocol.Metas = []*document{protocolMeta}
+	return protocol
+}
+
+func isProtocol(object Object) (*document, bool) {
+	doc, ok := object.(*document)
+	if !ok {
+		return nil, false
+	}
+	if is, err := IsInstance(doc, protocolMeta); err != nil || !is {
+		return nil, false
+	}
+	return doc, true
+}
+
+// members of protocol not found in doc and its metas,
+// native functions are not checked for arity
+func MissingMembers(object Object, protocol *document) ([]string, error) {
+	doc, _ := object.(*document)
+	missing := []string{}
+	for _, m := range protocol.List {
+		member := m.(*document)
+		name := member.Attrs.At("name").(*string_).Value
+		arity, hasArity := member.Attrs.At("arity").(*number)
+
+		var attr Object
+		ok := false
+		if doc != nil {
+			var err error
+			attr, ok, err = LookupAttr(doc, name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if !hasArity {
+			continue
+		}
+		f, ok := attr.(*function)
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s is not a function", name))
+		} else if f.Native == nil && len(f.Parameters) != int(arity.Value) {
+			missing = append(missing, fmt.Sprintf(
+				"%s want %d parameter(s), has %d",
+				name,
+				int(arity.Value),
+				len(f.Parameters),
+			))
+		}
+	}
+	return missing, nil
+}
+
+func conform(object Object, protocol *document) error {
+	missing, err := MissingMembers(object, protocol)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"%s does not conform to %s: %s",
+		object.Type(),
+		protocol.Attrs.At("name").(*string_).Value,
+		strings.Join(missing, ", "),
+	)
+}
@@ -0,0 +1,63 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+	"wildscript/internal/ast"
+	"wildscript/internal/lexer"
+)
+
+const NODE ObjectType = "node"
+
+// syntax tree node carried by quote document
+type node struct {
+	Node ast.Node
+}
+
+func (n *node) Type() ObjectType { return NODE }
+func (n *node) Inspect() string  { return n.Node.String() }
+
+// prints quoted code
+var quoteMeta = func() *document {
+	quote := NewDocument()
+	quote.Attrs.Set("__str", NewNativeMethod(func(
+		be blockEvaluator,
+		self Object,
+		args ...Object,
+	) (Object, error) {
+		return self.(*document).Attrs.At("code"), nil
+	}))
+	return quote
+}()
+
+// quote document has kind of node, its code and node itself
+func NewQuote(n ast.Node) *document {
+	q := NewDocument()
+	q.Attrs.Set("kind", NewString(
+		strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."),
+	))
+	q.Attrs.Set("code", NewString(n.String()))
+	q.Attrs.Set("node", &node{Node: n})
+	q.Metas = []*document{quoteMeta}
+	return q
+}
+
+// node of quote document or literal for simple value,
+// literals get position of token
+func QuotedNode(object Object, token lexer.Token) (ast.Node, error) {
+	switch object := object.(type) {
+	case *document:
+		if n, ok := object.Attrs.At("node").(*node); ok {
+			return n.Node, nil
+		}
+	case *number:
+		return &ast.NumberLiteral{Token: token, Value: object.Value}, nil
+	case *string_:
+		return &ast.StringLiteral{Token: token, Value: object.Value}, nil
+	case *boolean:
+		return &ast.BooleanLiteral{Token: token, Value: object.Value}, nil
+	case *nil_:
+		return &ast.NilLiteral{Token: token}, nil
+	}
+	return nil, fmt.Errorf("can not splice %s into code", object.Type())
+}
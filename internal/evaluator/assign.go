@@ -109,18 +109,16 @@ func (e *Evaluator) evalSliceAssign(
 	object := e.Eval(left.Left)
 	start := e.Eval(left.Start)
 	end := e.Eval(left.End)
+	step := e.Eval(left.Step)
 
-	if (start.Type() != environment.NUMBER &&
-		start.Type() != environment.NIL) ||
-		(end.Type() != environment.NUMBER &&
-			end.Type() != environment.NIL) {
+	if !isIndex(start) || !isIndex(end) || !isIndex(step) {
 		lib.Die(
 			left.Token,
 			"non num index",
 		)
 	}
 
-	result, err := environment.MetaCall(object, "__set_slice", e, nil, start, end, value)
+	result, err := environment.MetaCall(object, "__set_slice", e, nil, start, end, step, value)
 	if err != nil {
 		lib.Die(
 			left.Token,
@@ -137,7 +135,7 @@ func (e *Evaluator) evalKeyAssign(
 	object := e.Eval(left.Left)
 	key := e.Eval(left.Key)
 
-	if key.Type() == environment.NIL {
+	if left.Whole() {
 		result, err := environment.MetaCall(object, "__set_dict", e, nil, value)
 		if err != nil {
 			lib.Die(
@@ -0,0 +1,55 @@
+package evaluator
+
+import (
+	"wildscript/internal/ast"
+	"wildscript/internal/environment"
+	"wildscript/internal/lib"
+)
+
+func (e *Evaluator) evalDeleteStatement(
+	node *ast.DeleteStatement,
+) environment.Object {
+	var err error
+
+	switch target := node.Target.(type) {
+	case *ast.AttributeExpression:
+		object := e.Eval(target.Left)
+		prop := environment.NewString(target.Attribute.Value)
+		_, err = environment.MetaCall(object, "__del_attribute", e, nil, prop)
+	case *ast.KeyExpression:
+		object := e.Eval(target.Left)
+		key := e.Eval(target.Key)
+		_, err = environment.MetaCall(object, "__del_key", e, nil, key)
+	case *ast.IndexExpression:
+		object := e.Eval(target.Left)
+		index := e.Eval(target.Index)
+		if index.Type() != environment.NUMBER {
+			lib.Die(
+				target.Token,
+				"non num index",
+			)
+		}
+		_, err = environment.MetaCall(object, "__del_index", e, nil, index)
+	case *ast.SliceExpression:
+		object := e.Eval(target.Left)
+		start := e.Eval(target.Start)
+		end := e.Eval(target.End)
+		step := e.Eval(target.Step)
+		if !isIndex(start) || !isIndex(end) || !isIndex(step) {
+			lib.Die(
+				target.Token,
+				"non num index",
+			)
+		}
+		_, err = environment.MetaCall(object, "__del_slice", e, nil, start, end, step)
+	}
+
+	if err != nil {
+		lib.Die(
+			node.Token,
+			err.Error(),
+		)
+	}
+
+	return environment.NewNil()
+}
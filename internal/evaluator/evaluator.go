@@ -11,20 +11,30 @@ import (
 )
 
 type Evaluator struct {
-	env *environment.Environment
+	env     *environment.Environment
+	pragmas ast.Pragmas
 }
 
 func New(env *environment.Environment) *Evaluator {
-	e := &Evaluator{env: environment.New(env)}
+	e := &Evaluator{
+		env:     environment.New(env),
+		pragmas: ast.EditionPragmas(ast.LatestEdition),
+	}
 	return e
 }
 
+// program sets its own pragmas when evaluated,
+// used when statements of program are evaluated one by one
+func (e *Evaluator) SetPragmas(pragmas ast.Pragmas) {
+	e.pragmas = pragmas
+}
+
 func (e *Evaluator) Eval(node ast.Node) environment.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return e.evalProgram(node)
 	case *ast.BlockExpression:
-		return e.EvalBlock(node, e.env, nil)
+		return e.EvalBlock(node, e.env, nil, e.pragmas)
 
 	case *ast.AssignStatement:
 		return e.evalAssignStatement(node)
@@ -56,6 +66,28 @@ func (e *Evaluator) Eval(node ast.Node) environment.Object {
 		return e.evalRepeatStatement(node)
 	case *ast.ForStatement:
 		return e.evalForStatement(node)
+	case *ast.WithStatement:
+		return e.evalWithStatement(node)
+	case *ast.DeleteStatement:
+		return e.evalDeleteStatement(node)
+	case *ast.EnumStatement:
+		return e.evalEnumStatement(node)
+	case *ast.ProtocolStatement:
+		return e.evalProtocolStatement(node)
+	case *ast.OperatorStatement:
+		return e.evalLetStatement(
+			&ast.LetStatement{
+				Token: node.Token,
+				Left: &ast.Identifier{
+					Token: node.Token,
+					Value: node.Operator,
+				},
+				Right: node.Function,
+			},
+		)
+	case *ast.MacroStatement:
+		// expanded before evaluation
+		return environment.NewNil()
 
 	case *ast.InfixExpression:
 		return e.evalInfixExpression(node)
@@ -73,6 +105,14 @@ func (e *Evaluator) Eval(node ast.Node) environment.Object {
 		return e.evalAttributeExpression(node)
 	case *ast.KeyExpression:
 		return e.evalKeyExpression(node)
+	case *ast.QuoteExpression:
+		return e.evalQuoteExpression(node)
+	case *ast.UnquoteExpression:
+		lib.Die(
+			node.Token,
+			"unquote outside of quote",
+		)
+		return nil
 
 	case *ast.Identifier:
 		return e.evalIdentifier(node)
@@ -86,6 +126,7 @@ func (e *Evaluator) Eval(node ast.Node) environment.Object {
 			node.Body,
 			e.env,
 			node.Impl,
+			e.pragmas,
 		)
 	case *ast.NumberLiteral:
 		return environment.NewNumber(node.Value)
@@ -105,6 +146,8 @@ func (e *Evaluator) Eval(node ast.Node) environment.Object {
 }
 
 func (e *Evaluator) evalProgram(program *ast.Program) environment.Object {
+	e.pragmas = program.Pragmas
+
 	var result environment.Object
 	for _, stmt := range program.Statements {
 		result = e.Eval(stmt)
@@ -125,10 +168,12 @@ func (e *Evaluator) EvalBlock(
 	block *ast.BlockExpression,
 	outer *environment.Environment,
 	args map[string]environment.Object,
+	pragmas ast.Pragmas,
 ) environment.Object {
 	var result environment.Object
 
 	blockEval := New(outer)
+	blockEval.pragmas = pragmas
 	for key, val := range args {
 		blockEval.env.Create(key, val)
 	}
@@ -169,7 +214,7 @@ func (e *Evaluator) evalImportStatement(
 
 	l := lexer.New(input)
 	p := parser.New(l)
-	mod := p.ParseProgram()
+	mod := ExpandMacros(p.ParseProgram())
 
 	modEv := New(nil)
 
@@ -199,20 +244,122 @@ func (e *Evaluator) evalIdentifier(
 func (e *Evaluator) evalDocumentLiteral(
 	node *ast.DocumentLiteral,
 ) environment.Object {
+	return e.evalDocument(node, false)
+}
+
+// literal written as dict key is frozen with literals inside it,
+// so it can be hashed by contents
+func (e *Evaluator) evalDocument(
+	node *ast.DocumentLiteral,
+	frozen bool,
+) environment.Object {
+	element := func(expr ast.Expression, frozen bool) environment.Object {
+		if lit, ok := expr.(*ast.DocumentLiteral); ok && frozen {
+			return e.evalDocument(lit, true)
+		}
+		return e.Eval(expr)
+	}
+
 	doc := environment.NewDocument()
 	for _, elem := range node.Elements {
 		switch elem.Type {
 		case ast.LIST:
-			val := e.Eval(elem.Value)
+			val := element(elem.Value, frozen)
 			doc.List = append(doc.List, val)
 		case ast.DICT:
-			key, val := e.Eval(elem.Key), e.Eval(elem.Value)
-			doc.Dict.Set(key, val)
+			key, val := element(elem.Key, true), element(elem.Value, frozen)
+			if err := doc.Dict.Set(e, key, val); err != nil {
+				lib.Die(
+					elem.Token,
+					err.Error(),
+				)
+			}
 		case ast.PROP:
 			key := elem.Key.(*ast.Identifier).Value
-			val := e.Eval(elem.Value)
-			doc.Attrs[key] = val
+			val := element(elem.Value, frozen)
+			doc.Attrs.Set(key, val)
 		}
 	}
+	if frozen {
+		environment.Freeze(doc)
+	}
 	return doc
 }
+
+// members without value continue numbering from previous one
+func (e *Evaluator) evalEnumStatement(
+	node *ast.EnumStatement,
+) environment.Object {
+	names := []string{}
+	values := []environment.Object{}
+
+	var next environment.Object = environment.NewNumber(0)
+	for _, member := range node.Members {
+		var value environment.Object
+		if member.Value != nil {
+			value = e.Eval(member.Value)
+		} else if next != nil {
+			value = next
+		} else {
+			lib.Die(
+				member.Token,
+				"enum member %s needs value",
+				member.Name.Value,
+			)
+		}
+
+		next = nil
+		if value.Type() == environment.NUMBER {
+			next, _ = environment.MetaCall(value, "__add", e, nil, environment.NewNumber(1))
+		}
+
+		names = append(names, member.Name.Value)
+		values = append(values, value)
+	}
+
+	enum, err := environment.NewEnum(node.Name.Value, names, values)
+	if err != nil {
+		lib.Die(
+			node.Token,
+			err.Error(),
+		)
+	}
+
+	result, ok := e.env.Create(node.Name.Value, enum)
+	if !ok {
+		lib.Die(
+			node.Token,
+			"variable %s already exists",
+			node.Name.Value,
+		)
+	}
+	return result
+}
+
+func (e *Evaluator) evalProtocolStatement(
+	node *ast.ProtocolStatement,
+) environment.Object {
+	members := []environment.ProtocolMember{}
+	for _, member := range node.Members {
+		var arity environment.Object = environment.NewNil()
+		if member.Parameters != nil {
+			arity = environment.NewNumber(float64(len(member.Parameters)))
+		}
+		members = append(members, environment.ProtocolMember{
+			Name:  member.Name.Value,
+			Arity: arity,
+		})
+	}
+
+	protocol := environment.NewProtocol(node.Name.Value, members)
+
+	result, ok := e.env.Create(node.Name.Value, protocol)
+	if !ok {
+		lib.Die(
+			node.Token,
+			"variable %s already exists",
+			node.Name.Value,
+		)
+	}
+	return result
+}
@@ -0,0 +1,511 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"wildscript/internal/lexer"
+	"wildscript/internal/parser"
+)
+
+// exported value of program or message of panic
+func run(input string) (result string, err string) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Sprint(p)
+		}
+	}()
+	program := ExpandMacros(parser.New(lexer.New([]byte(input))).ParseProgram())
+	return New(nil).Eval(program).Inspect(), ""
+}
+
+type evalTest struct {
+	input string
+	want  string // exported value
+	err   string // part of expected error, empty when program must succeed
+}
+
+func runEvalTests(t *testing.T, tests []evalTest) {
+	t.Helper()
+	for _, tt := range tests {
+		result, err := run(tt.input)
+		switch {
+		case tt.err != "" && !strings.Contains(err, tt.err):
+			t.Errorf("%q: want error %q, got %q (result %s)", tt.input, tt.err, err, result)
+		case tt.err == "" && err != "":
+			t.Errorf("%q: unexpected error %s", tt.input, err)
+		case tt.err == "" && result != tt.want:
+			t.Errorf("%q: want %s, got %s", tt.input, tt.want, result)
+		}
+	}
+}
+
+func TestMacros(t *testing.T) {
+	swap := `
+macro swap(a, b) {
+    quote {
+        let tmp = unquote(a)
+        unquote(a) = unquote(b)
+        unquote(b) = tmp
+    }
+}
+`
+	runEvalTests(t, []evalTest{
+		{swap + "let x = 1\nlet y = 2\nswap(x, y)\nexport {x, y}", "{2, 1}", ""},
+		// names declared inside quote do not capture variables of program
+		{swap + "let tmp = 1\nlet y = 2\nswap(tmp, y)\nexport {tmp, y}", "{2, 1}", ""},
+		{"macro twice(x) { quote { unquote(x) * 2 } }\nexport twice(twice(3))", "12", ""},
+		{"macro unless(c, body) { quote { if not unquote(c) then { unquote(body) } } }\n" +
+			"let r = 0\nfunction set(v) { r = v }\nunless(false, set(1))\nunless(true, set(2))\nexport r", "1", ""},
+		{"macro kind(x) { x.kind }\nexport kind(1 + 2)", "InfixExpression", ""},
+		{"macro one(x) { x }\none(1, 2)", "", "macro one wants 1 argument(s), got 2"},
+		// free name is renamed only after quote binds it
+		{"macro shadow() { quote { let before = tmp\nlet tmp = 2\n{before, tmp} } }\n" +
+			"let tmp = 1\nexport shadow()", "{1, 2}", ""},
+		{"macro inner() { quote { let f = lambda(tmp) { return tmp * 10 }\nf(2) + tmp } }\n" +
+			"let tmp = 1\nexport inner()", "21", ""},
+		{"macro loop() { quote { let s = 0\nfor tmp in {1, 2}[] do { s = s + tmp }\ns + tmp } }\n" +
+			"let tmp = 100\nexport loop()", "103", ""},
+	})
+}
+
+func TestPragmas(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"export nil or 1", "", "not bool value nil"},
+		{"pragma no_strict\nexport nil or 1", "1", ""},
+		{"pragma no_strict\nexport 0 and 1", "0", ""},
+		{"let n = 0\nfunction f() { n = n + 1; return true }\nlet r = true or f()\nexport n", "0", ""},
+		{"pragma no_short_circuit\nlet n = 0\nfunction f() { n = n + 1; return true }\nlet r = true or f()\nexport n", "1", ""},
+		{"#!edition 1\nlet n = 0;\nfunction f() { n = n + 1; return true };\nlet r = true or f();\nexport n;", "1", ""},
+		{"#!edition 3\nexport 1", "", "unknown edition: 3"},
+		{"pragma fast\nexport 1", "", "unknown pragma: fast"},
+	})
+}
+
+// function runs with pragmas of module where it is defined
+func TestLexicalPragmas(t *testing.T) {
+	t.Chdir(t.TempDir())
+	modules := map[string]string{
+		"lax.wild":    "pragma no_strict\nexport {f = lambda(x) { return x or 1 }}",
+		"strict.wild": "export {f = lambda(x) { return x or 1 }}",
+	}
+	for name, source := range modules {
+		if err := os.WriteFile(name, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runEvalTests(t, []evalTest{
+		{"import lax\nexport lax.f(nil)", "1", ""},
+		{"pragma no_strict\nimport strict\nexport strict.f(nil)", "", "not bool value nil"},
+	})
+}
+
+func TestMRO(t *testing.T) {
+	classes := `
+let O = {n = "O", who = method(self) { return "O" }}
+let A = {n = "A"}
+let B = {n = "B", who = method(self) { return "B" }}
+let C = {n = "C", who = method(self) { return "C" }}
+set_meta(A, O)
+set_meta(B, O)
+set_meta(C, O)
+function order(cls) {
+    let s = ""
+    for c in mro(cls)[] do { s = s + c.n }
+    return s
+}
+`
+	runEvalTests(t, []evalTest{
+		{classes + "let D = {n = \"D\"}\nset_meta(D, A, B, C)\nexport order(D)", "DABCO", ""},
+		{classes + "let x = {}\nset_meta(x, A, B)\nexport x.who()", "B", ""},
+		{classes + "let x = {}\nset_meta(x, A, C, B)\nexport x.who()", "C", ""},
+		{classes + "let D = {}\nset_meta(D, A, B)\nlet E = {}\nset_meta(E, B, A)\n" +
+			"let F = {}\nset_meta(F, D, E)", "", "inconsistent meta order"},
+		// order of D is broken by later set_meta on its meta
+		{classes + "let D = {}\nset_meta(D, A, B)\nset_meta(B, A)\nexport D.n", "", "inconsistent meta order"},
+		{classes + "set_meta(O, A)", "", "meta cycle"},
+		// cached order follows metas of metas and of unrelated documents
+		{classes + "let x = {}\nset_meta(x, A)\nx.who()\nlet P = {n = \"P\", who = method(self) { return \"P\" }}\n" +
+			"set_meta(A, P)\nexport x.who() + order(x)", "PAAP", ""},
+		{classes + "let x = {}\nset_meta(x, B)\nx.who()\nset_meta(C, B)\nexport x.who() + order(x)", "BBBO", ""},
+	})
+}
+
+func TestAccessors(t *testing.T) {
+	temp := `
+let Temp = {
+    __get_fahrenheit = method(self) { return self.celsius * 9 / 5 + 32 },
+    __set_fahrenheit = method(self, f) { self.celsius = (f - 32) * 5 / 9 },
+    __missing_attribute = method(self, name) { return "no " + name }
+}
+let t = {celsius = 100}
+set_meta(t, Temp)
+`
+	runEvalTests(t, []evalTest{
+		{temp + "export t.fahrenheit", "212", ""},
+		{temp + "t.fahrenheit = 32\nexport t.celsius", "0", ""},
+		{temp + "export t.color", "no color", ""},
+		{"let d = {}\nexport d.color", "", "attribute not exists"},
+		// getter goes before stored value
+		{"let M = {__get_x = method(self) { return 1 }}\n" +
+			"let d = {x = 2}\nset_meta(d, M)\nexport d.x", "1", ""},
+		{"let M = {__get_x = 1}\nlet d = {}\nset_meta(d, M)\nexport d.x", "", "accessor is number, not a function"},
+		// metamethods are not taken for accessors
+		{"let M = {__set_index = method(self, i, v) { return self }}\n" +
+			"let d = {}\nset_meta(d, M)\nd.index = 5\nexport d.index", "5", ""},
+	})
+}
+
+func TestDel(t *testing.T) {
+	doc := "let d = {10, 20, 30, 40, 50, a = 1, b = 2, \"k\": 1, \"j\": 2}\n"
+	runEvalTests(t, []evalTest{
+		{doc + "del d.a\nexport repr(d)", `{10, 20, 30, 40, 50, b = 2, "k": 1, "j": 2}`, ""},
+		{doc + "del d{\"k\"}\nexport repr(d)", `{10, 20, 30, 40, 50, a = 1, b = 2, "j": 2}`, ""},
+		{doc + "del d[0]\ndel d[-1]\nexport repr(d)", `{20, 30, 40, a = 1, b = 2, "k": 1, "j": 2}`, ""},
+		{doc + "del d[::2]\nexport repr(d)", `{20, 40, a = 1, b = 2, "k": 1, "j": 2}`, ""},
+		{doc + "del d[1:3]\nexport repr(d)", `{10, 40, 50, a = 1, b = 2, "k": 1, "j": 2}`, ""},
+		{doc + "del d.zzz", "", "attribute not exists"},
+		{"let d = freeze({1})\ndel d[0]", "", "document is frozen"},
+		{"let M = {__del_attribute = method(self, name) { self.deleted = name }}\n" +
+			"let m = {}\nset_meta(m, M)\ndel m.x\nexport m.deleted", "x", ""},
+	})
+}
+
+func TestEqualityAndHash(t *testing.T) {
+	point := `
+let Pt = {
+    __eq = method(self, o) { return self.x == o.x },
+    __hash = method(self) { return self.x }
+}
+let p = {x = 1, y = 2}
+set_meta(p, Pt)
+let q = {x = 1, y = 5}
+set_meta(q, Pt)
+`
+	runEvalTests(t, []evalTest{
+		{"export {1, 2, x = 3} == {1, 2, x = 3}", "true", ""},
+		{"export {1, 2} == {2, 1}", "false", ""},
+		{"export {x = 1, y = 2} == {y = 2, x = 1}", "true", ""},
+		{"export hash({1, x = 2}) == hash({1, x = 2})", "true", ""},
+		{"export hash(1) == hash(1.0)", "true", ""},
+		{"let a = {}\na.self = a\nlet b = {}\nb.self = b\nexport a == b", "true", ""},
+		{"let a = {}\na.self = a\nlet b = {}\nb.self = b\nexport hash(a) == hash(b)", "true", ""},
+		{point + "export p == q", "true", ""},
+		{point + "let d = {p: \"p\"}\nexport d{q}", "p", ""},
+		{"let d = {true: 1, nil: 2, 1: 3}\nexport d{nil}", "2", ""},
+		{"let d = {freeze({x = 1}): 1}\nexport d{{x = 1}}", "1", ""},
+		// literal key is frozen with literals inside it
+		{"let d = {{1, {2}}: 1}\nexport d{{1, {2}}}", "1", ""},
+		{"let k = {x = 1}\nlet d = {}\nd{k} = 1", "", "unfrozen document can not be key"},
+		{"let k = freeze({{}})\nlet d = {}\nd{k} = 1", "", "unfrozen document can not be key"},
+		{"let x = {}\nlet d = {{x}: 1}", "", "unfrozen document can not be key"},
+		{"let E = {__eq = method(self, o) { return true }}\nlet e = {}\nset_meta(e, E)\nexport hash(e)",
+			"", "document with __eq must have __hash"},
+		{"enum Color { RED, GREEN }\nlet d = {}\nd{Color.RED} = \"r\"\nexport d{Color.RED}", "r", ""},
+		{"enum Color { RED, GREEN }\nlet d = {Color.RED: 1}\nexport d{Color.GREEN}", "", "key not exists"},
+	})
+}
+
+// != is negated == unless __ne is defined
+func TestNotEqual(t *testing.T) {
+	always := `
+let Always = {__eq = method(self, o) { return true }, __hash = method(self) { return 0 }}
+let a = {x = 1}
+set_meta(a, Always)
+`
+	runEvalTests(t, []evalTest{
+		{always + "export {a == {x = 2}, a != {x = 2}}", "{true, false}", ""},
+		{always + "export {{x = 2} == a, {x = 2} != a}", "{true, false}", ""},
+		{"let N = {__ne = method(self, o) { return \"ne\" }}\nlet n = {}\nset_meta(n, N)\nexport n != 1",
+			"", "not bool value"},
+		{"let N = {__ne = method(self, o) { return false }}\nlet n = {}\nset_meta(n, N)\nexport {n != 1, 1 != n}",
+			"{false, false}", ""},
+		{"export {{1} != {1}, {1} != {2}, 1 != \"1\", nil != nil}", "{false, true, true, false}", ""},
+	})
+}
+
+func TestPretty(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{`export pretty({1, "a", x = nil})`, `{1, "a", x = nil}`, ""},
+		{"let d = {1}\nd.self = d\nexport pretty(d)", "{1, self = <cycle>}", ""},
+		{"export pretty({a = {b = {c = 1}}}, {depth = 2})", "{a = {b = {...}}}", ""},
+		{`export pretty({1, "k": 2}, {width = 8, indent = 2})`, "{\n  1,\n  \"k\": 2\n}", ""},
+		{"let M = {__str = method(self) { return \"m\" }}\nlet m = {}\nset_meta(m, M)\n" +
+			"export pretty({m, {m}})", "{m, {m}}", ""},
+		{"export pretty({}, {width = -1})", "", "width must be non-negative number"},
+	})
+}
+
+// repr is read back as equal value with the same repr
+func TestReprRoundTrip(t *testing.T) {
+	for _, literal := range []string{
+		`{1, -2.5, 1e+21, 1000000, 0.1}`,
+		`{"a\tb\x01я\"\\", "😀", ""}`,
+		`{true, false, nil, items = {}}`,
+		`{name = "wild", "key": {x = 1}, 3: "three", nil: {1}}`,
+		`{{1, {2}}: "doc key", true: nil}`,
+	} {
+		repr, err := run("export repr(" + literal + ")")
+		if err != "" {
+			t.Fatalf("%s: %s", literal, err)
+		}
+		again, err := run("export repr(" + repr + ")")
+		if err != "" || again != repr {
+			t.Errorf("%s: repr %s read back as %s %s", literal, repr, again, err)
+		}
+		if eq, err := run("export " + literal + " == " + repr); eq != "true" {
+			t.Errorf("%s: not equal to its repr %s %s", literal, repr, err)
+		}
+	}
+
+	runEvalTests(t, []evalTest{
+		{"export repr(lambda() {})", "", "can not represent function"},
+		{"let d = {}\nd.self = d\nexport repr(d)", "", "can not represent cyclic document"},
+	})
+}
+
+func TestNumberFormat(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"export str(1e6)", "1000000", ""},
+		{"export str(1_000_000)", "1000000", ""},
+		{"export str(-3e7)", "-30000000", ""},
+		{"export str(1e21)", "1e+21", ""},
+		{"export str(1.5e-3)", "0.0015", ""},
+		{"export str(0xFF + 0b1 + 0o7)", "263", ""},
+	})
+}
+
+func TestReflectedOperators(t *testing.T) {
+	vec := `
+let Vec = {
+    __mul = method(self, k) { return self.x * k },
+    __rmul = method(self, k) { return self.x * k * 10 },
+    __gt = method(self, o) { return true }
+}
+let v = {x = 2}
+set_meta(v, Vec)
+`
+	runEvalTests(t, []evalTest{
+		{vec + "export v * 3", "6", ""},
+		{vec + "export 3 * v", "60", ""},
+		// < of number and document is > of document
+		{vec + "export 1 < v", "true", ""},
+		{vec + "export v + 1", "", "unsupported operand types for +: document and number"},
+		{vec + "export 1 - v", "", "unsupported operand types for -: number and document"},
+		{`export "n=" + 5`, "n=5", ""},
+		{`export 5 + "x"`, "5x", ""},
+		{`export 1 - "a"`, "", "unsupported operand types for -: number and string"},
+		{`export {1 == nil, nil == nil, "a" != 1, true == 1}`, "{false, true, true, false}", ""},
+		// identity decides == of values without __eq for each other
+		{"let f = lambda() {}\nexport {f == f, f != f, f == lambda() {}}", "{true, false, false}", ""},
+		{"let Eq = {__eq = method(self, o) { return o == 1 }, __hash = method(self) { return 1 }}\n" +
+			"let e = {}\nset_meta(e, Eq)\nexport {e == 1, 1 == e, e != 1, 1 != e, e != 2}",
+			"{true, true, false, false, true}", ""},
+	})
+}
+
+func TestEnum(t *testing.T) {
+	color := "enum Color { RED, GREEN, BLUE = 10, CYAN }\n"
+	runEvalTests(t, []evalTest{
+		{color + "export {Color.RED == Color.RED, Color.RED == Color.GREEN, Color.RED != Color.GREEN}", "{true, false, true}", ""},
+		// members with equal values are still distinct
+		{"enum M { A = 1, B = 1 }\nexport {M.A == M.B, M.A.value == M.B.value}", "{false, true}", ""},
+		{color + "let s = \"\"\nfor name, m in Color do { s = s + name + str(m.value) + \" \" }\nexport s",
+			"RED0 GREEN1 BLUE10 CYAN11 ", ""},
+		{color + "export {len(Color), Color[-1].name, Color.GREEN.name}", `{4, "CYAN", "GREEN"}`, ""},
+		{color + "export {str(Color.RED), str(Color)}", `{"Color.RED", "enum Color"}`, ""},
+		{"enum Mode { READ = \"r\", WRITE = \"w\" }\nexport Mode.WRITE.value", "w", ""},
+		{color + "let d = {Color.RED: \"r\", Color.BLUE: \"b\"}\nexport d{Color.BLUE}", "b", ""},
+		{color + "Color.RED = 1", "", "document is frozen"},
+		{color + "Color.WHITE = 1", "", "document is frozen"},
+		{color + "Color.RED.value = 2", "", "document is frozen"},
+		{color + "export is_frozen(Color) and is_frozen(Color.RED)", "true", ""},
+		{"enum M { A, A }", "", "enum member A already exists"},
+		{"enum M { A = \"x\", B }", "", "enum member B needs value"},
+		{color + "export Color.RED < Color.GREEN", "", "unsupported operand types for <"},
+	})
+}
+
+func TestWith(t *testing.T) {
+	resource := `
+let log = {s = ""}
+let Res = {
+    __enter = method(self) { log.s = log.s + "enter "; return self.name },
+    __exit = method(self, err) { log.s = log.s + "exit " + type(err) }
+}
+let r = {name = "r"}
+set_meta(r, Res)
+`
+	runEvalTests(t, []evalTest{
+		{resource + "with r as n do { log.s = log.s + n + \" \" }\nexport log.s", "enter r exit nil", ""},
+		{resource + "function f() { with r do { return 5 }\nreturn 1 }\nexport str(f()) + \" \" + log.s", "5 enter exit nil", ""},
+		{resource + "with r do { missing }", "", "undefined variable: missing"},
+		{"with {} do { 1 }", "", "no __enter method in document"},
+		{"let R = {__enter = method(self) { return 1 }}\nlet r = {}\nset_meta(r, R)\nwith r do { 1 }", "", "no __exit method in document"},
+	})
+
+	// __exit gets error of block before panic goes on
+	program := ExpandMacros(parser.New(lexer.New([]byte(resource + "with r do { missing }"))).ParseProgram())
+	e := New(nil)
+	func() {
+		defer func() { recover() }()
+		e.Eval(program)
+	}()
+	log, _ := e.env.Get("log")
+	if got := log.Inspect(); got != `{s = "enter exit document"}` {
+		t.Errorf("want __exit with error document, got %s", got)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	classes := `
+let Human = {
+    n = "Human",
+    create = static(cls, name) {
+        let human = {name = name}
+        set_meta(human, cls)
+        return human
+    },
+    kind = static(cls) { return cls.n }
+}
+let Witch = {n = "Witch"}
+set_meta(Witch, Human)
+let adam = Human.create("Adam")
+`
+	runEvalTests(t, []evalTest{
+		{classes + "export {isinstance(adam, Human), isinstance(adam, Witch), isinstance(5, Human)}",
+			"{true, false, false}", ""},
+		{classes + "export class_of(adam) == Human", "true", ""},
+		{classes + "export {class_of(5), len(bases(Witch)), len(bases(Human))}", "{nil, 1, 0}", ""},
+		// static function gets class holding it, never the instance
+		{classes + "export {adam.kind(), Human.kind(), Witch.kind()}", `{"Human", "Human", "Human"}`, ""},
+		{classes + "let f = adam.kind\nexport f()", "Human", ""},
+		{classes + "export Human.kind", "function<bound static>", ""},
+		{"let s = static(cls) { return cls }\nexport s(5)", "5", ""},
+		{"let s = static(cls) { return cls }\ns()", "", "static called without class"},
+		{classes + "export isinstance(adam, 5)", "", "isinstance want document class, got number"},
+		{"export bases(1)", "", "bases want document class, got number"},
+	})
+}
+
+func TestForPairs(t *testing.T) {
+	doc := "let doc = {\"a\", \"b\", name = \"wild\", age = 3, \"k\": 1, 2: \"two\"}\nlet s = \"\"\n"
+	runEvalTests(t, []evalTest{
+		{doc + "for i, v in doc[] do { s = s + str(i) + v }\nexport s", "0a1b", ""},
+		{doc + "for k, v in doc{} do { s = s + str(k) + str(v) }\nexport s", "k12two", ""},
+		{doc + "for v in doc{} do { s = s + str(v) }\nexport s", "1two", ""},
+		{doc + "for name, value in attrs(doc) do { s = s + name + str(value) }\nexport s", "namewildage3", ""},
+		// loop variables do not leak
+		{doc + "for i, v in doc[] do { }\nexport i", "", "undefined variable: i"},
+		{"for k, v in 5 do { }", "", "no __iter method in number"},
+	})
+}
+
+func TestNegativeIndicesAndSlices(t *testing.T) {
+	doc := `
+let doc = {0, 1, 2, 3, 4, 5}
+function items(slice) {
+    let s = ""
+    for v in slice do { s = s + str(v) + " " }
+    return s
+}
+`
+	runEvalTests(t, []evalTest{
+		{doc + "export {doc[-1], doc[-6]}", "{5, 0}", ""},
+		{doc + "export items(doc[::-1])", "5 4 3 2 1 0 ", ""},
+		{doc + "export items(doc[1::2])", "1 3 5 ", ""},
+		{doc + "export items(doc[-2:])", "4 5 ", ""},
+		// slice bounds are clamped
+		{doc + "export items(doc[10:20])", "", ""},
+		{doc + "export items(doc[-100:2])", "0 1 ", ""},
+		{`let s = "hello"` + "\nexport {s[-3:], s[::-1], s[::2], s[1:-1], s[-100:100]}",
+			`{"llo", "olleh", "hlo", "ell", "hello"}`, ""},
+		{doc + "doc[-1] = 50\nexport doc[5]", "50", ""},
+		{doc + "doc[::2] = {\"a\", \"b\", \"c\"}\nexport doc", `{"a", 1, "b", 3, "c", 5}`, ""},
+		{doc + "doc[1:3] = {\"x\"}\nexport doc", `{0, "x", 3, 4, 5}`, ""},
+		{doc + "doc[-2:] = {}\nexport doc", "{0, 1, 2, 3}", ""},
+		{doc + "doc[6]", "", "index out of range"},
+		{doc + "doc[-7]", "", "index out of range"},
+		{doc + "doc[-7] = 1", "", "index out of range"},
+		{`"ab"[2]`, "", "index out of range"},
+		{doc + "doc[::0]", "", "slice step cannot be zero"},
+		{`"ab"[::0]`, "", "slice step cannot be zero"},
+		{doc + "doc[::2] = {1}", "", "assign 1 element(s) to extended slice of 3"},
+	})
+}
+
+func TestBoundMethods(t *testing.T) {
+	doc := "let doc = {name = \"Zullie\", greet = method(self, x) { return self.name + str(x) }}\n"
+	runEvalTests(t, []evalTest{
+		{doc + "export doc.greet(1)", "Zullie1", ""},
+		{doc + "let f = doc.greet\nexport f(2)", "Zullie2", ""},
+		{doc + "function run(cb) { return cb(3) }\nexport run(doc.greet)", "Zullie3", ""},
+		// bound method keeps its first receiver
+		{doc + "let other = {name = \"Other\", g = doc.greet}\nexport other.g(4)", "Zullie4", ""},
+		{doc + "export doc.greet", "function<bound method>", ""},
+		{"let l = {1, 2}\nlet app = l[].append\napp(5)\nexport {l[2], app}", "{5, function<bound native>}", ""},
+		{"let m = method(self) { return self }\nexport m(7)", "7", ""},
+		{"let m = method(self) { return self }\nm()", "", "method called without self"},
+	})
+}
+
+func TestProtocols(t *testing.T) {
+	plugin := `
+protocol Plugin { load(self, path), unload(self), name }
+let Base = {name = "base", unload = method(self) { return nil }}
+let Good = {load = method(self, path) { return path }}
+set_meta(Good, Base)
+let g = {}
+set_meta(g, Good)
+`
+	runEvalTests(t, []evalTest{
+		// attributes are found through metas
+		{plugin + "export {implements(g, Plugin), implements(Base, Plugin), implements(5, Plugin)}", "{true, false, false}", ""},
+		{plugin + "export conform(Good, Plugin) == Good", "true", ""},
+		{plugin + "export str(Plugin)", "protocol Plugin", ""},
+		{plugin + "conform({name = 1, unload = method(self) {}}, Plugin)", "", "document does not conform to Plugin: load"},
+		{plugin + "conform({name = 1, load = method(self) {}, unload = method(self) {}}, Plugin)",
+			"", "load want 2 parameter(s), has 1"},
+		{plugin + "conform({name = 1, load = method(self, p) {}, unload = 5}, Plugin)", "", "unload is not a function"},
+		{plugin + "implements(g, 5)", "", "implements want protocol, got number"},
+	})
+}
+
+func TestFreeze(t *testing.T) {
+	cfg := "let cfg = freeze({1, 2, name = \"x\", inner = {a = 1}, \"k\": \"v\"})\n"
+	runEvalTests(t, []evalTest{
+		{cfg + "export {is_frozen(cfg), is_frozen(cfg.inner), is_frozen(5), is_frozen({})}", "{true, false, true, false}", ""},
+		// freeze is shallow
+		{cfg + "cfg.inner.a = 2\nexport cfg.inner.a", "2", ""},
+		{cfg + "deep_freeze(cfg)\ncfg.inner.a = 2", "", "document is frozen"},
+		// copy of frozen document can be changed
+		{cfg + "let c = cfg[].copy()\nc.append(3)\nexport c[2]", "3", ""},
+		{cfg + "cfg.name = \"y\"", "", "document is frozen at line 2"},
+		{cfg + "cfg[0] = 5", "", "document is frozen"},
+		{cfg + "cfg{\"k\"} = 5", "", "document is frozen"},
+		{cfg + "cfg[0:1] = {5}", "", "document is frozen"},
+		{cfg + "cfg[].append(5)", "", "document is frozen"},
+		{cfg + "cfg[].reverse()", "", "document is frozen"},
+		{cfg + "del cfg.name", "", "document is frozen"},
+		{cfg + "del cfg{\"k\"}", "", "document is frozen"},
+		{cfg + "set_meta(cfg, {})", "", "document is frozen"},
+		{"freeze(5)", "", "freeze want document, got number"},
+		{"deep_freeze(\"s\")", "", "deep_freeze want document, got string"},
+	})
+}
+
+func TestInsertionOrder(t *testing.T) {
+	doc := "let d = {z = 1, a = 2, m = 3, \"z\": 1, \"a\": 2, 10: 3}\n"
+	runEvalTests(t, []evalTest{
+		{doc + "export d", `{z = 1, a = 2, m = 3, "z": 1, "a": 2, 10: 3}`, ""},
+		// update keeps position, deleted entry goes to the end when set again
+		{doc + "d.z = 5\nd{\"z\"} = 5\nexport d", `{z = 5, a = 2, m = 3, "z": 5, "a": 2, 10: 3}`, ""},
+		{doc + "del d.z\nd.z = 1\ndel d{\"z\"}\nd{\"z\"} = 1\nexport d", `{a = 2, m = 3, z = 1, "a": 2, 10: 3, "z": 1}`, ""},
+		{doc + "let s = \"\"\nfor k, v in d{} do { s = s + str(k) }\nfor k, v in attrs(d) do { s = s + k }\nexport s", "za10zam", ""},
+		{doc + "let c = d{}.copy()\nlet s = \"\"\nfor k, v in c{} do { s = s + str(k) }\nexport s", "za10", ""},
+		{doc + "export repr(d)", `{z = 1, a = 2, m = 3, "z": 1, "a": 2, 10: 3}`, ""},
+		{doc + "del d{\"x\"}", "", "key not exists"},
+	})
+}
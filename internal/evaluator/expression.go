@@ -1,8 +1,11 @@
 package evaluator
 
 import (
+	"errors"
+	"fmt"
 	"wildscript/internal/ast"
 	"wildscript/internal/environment"
+	"wildscript/internal/lexer"
 	"wildscript/internal/lib"
 )
 
@@ -23,51 +26,180 @@ var binOps = map[string]string{
 	"!=": "__ne",
 }
 
+// meta method of right operand, comparisons swap direction
+var reflectedOps = map[string]string{
+	"+":  "__radd",
+	"-":  "__rsub",
+	"*":  "__rmul",
+	"/":  "__rdiv",
+	"//": "__rfloor_div",
+	"%":  "__rmod",
+	"^":  "__rpow",
+
+	"<":  "__gt",
+	"<=": "__ge",
+	">":  "__lt",
+	">=": "__le",
+}
+
 var unOps = map[string]string{
 	"-":   "__unm",
 	"not": "__not",
 }
 
+// operator registered in parser is dispatched to meta method,
+// reflected name is optional
+func RegisterBinaryOperator(operator, metaName, reflectedName string) {
+	binOps[operator] = metaName
+	if reflectedName != "" {
+		reflectedOps[operator] = reflectedName
+	}
+}
+
+func RegisterUnaryOperator(operator, metaName string) {
+	unOps[operator] = metaName
+}
+
 func (e *Evaluator) evalInfixExpression(
 	node *ast.InfixExpression,
 ) environment.Object {
+	if node.Operator == "and" ||
+		node.Operator == "or" {
+		return e.evalLogicalExpression(node)
+	}
+
 	left := e.Eval(node.Left)
 	right := e.Eval(node.Right)
 
-	if left.Type() != right.Type() {
+	if _, ok := binOps[node.Operator]; !ok {
+		return e.evalCustomOperator(node, left, right)
+	}
+
+	result, err := e.evalBinaryOperator(node.Operator, left, right)
+	if err != nil {
 		lib.Die(
 			node.Token,
-			"non equal operands type %s and %s",
-			left.Type(),
-			right.Type(),
+			err.Error(),
 		)
 	}
 
-	if node.Operator == "and" ||
-		node.Operator == "or" {
-		if left.Type() != environment.BOOLEAN {
-			lib.Die(
-				node.Token,
-				"non boolean condition",
-			)
+	return result
+}
+
+// left operand meta method first, then reflected one of right operand,
+// == and != are decided by environment.Equal and environment.NotEqual
+func (e *Evaluator) evalBinaryOperator(
+	operator string,
+	left environment.Object,
+	right environment.Object,
+) (environment.Object, error) {
+	switch operator {
+	case "==":
+		eq, err := environment.Equal(e, left, right)
+		return environment.NewBoolean(eq), err
+	case "!=":
+		ne, err := environment.NotEqual(e, left, right)
+		return environment.NewBoolean(ne), err
+	}
+
+	result, err := environment.MetaCall(left, binOps[operator], e, nil, right)
+	if !unsupported(err) {
+		return result, err
+	}
+
+	if reflected, ok := reflectedOps[operator]; ok {
+		result, err = environment.MetaCall(right, reflected, e, nil, left)
+		if !unsupported(err) {
+			return result, err
 		}
-		if node.Operator == "and" {
-			if b, _ := environment.CheckBool(left); b {
-				return right
-			} else {
-				return left
-			}
+	}
+
+	return nil, fmt.Errorf(
+		"unsupported operand types for %s: %s and %s",
+		operator,
+		left.Type(),
+		right.Type(),
+	)
+}
+
+func unsupported(err error) bool {
+	return errors.Is(err, environment.ErrUnsupported) ||
+		errors.Is(err, environment.ErrNoMeta)
+}
+
+// returns deciding operand, right one is evaluated
+// only if needed when short circuit pragma is on
+func (e *Evaluator) evalLogicalExpression(
+	node *ast.InfixExpression,
+) environment.Object {
+	left := e.Eval(node.Left)
+
+	var right environment.Object
+	if !e.pragmas.ShortCircuit {
+		right = e.Eval(node.Right)
+	}
+
+	if e.condition(left, node.Token) == (node.Operator == "or") {
+		return left
+	}
+
+	if right == nil {
+		right = e.Eval(node.Right)
+	}
+	if e.pragmas.Strict {
+		e.condition(right, node.Token)
+	}
+	return right
+}
+
+// strict condition must be boolean,
+// otherwise nil is false and others are decided by __bool
+func (e *Evaluator) condition(
+	object environment.Object,
+	token lexer.Token,
+) bool {
+	if !e.pragmas.Strict {
+		if object.Type() == environment.NIL {
+			return false
 		}
-		if node.Operator == "or" {
-			if b, _ := environment.CheckBool(left); b {
-				return left
-			} else {
-				return right
+		if object.Type() != environment.BOOLEAN {
+			b, err := environment.MetaCall(object, "__bool", e, nil)
+			if err != nil {
+				lib.Die(
+					token,
+					err.Error(),
+				)
 			}
+			object = b
 		}
 	}
 
-	result, err := environment.MetaCall(left, binOps[node.Operator], e, nil, right)
+	cond, err := environment.CheckBool(object)
+	if err != nil {
+		lib.Die(
+			token,
+			err.Error(),
+		)
+	}
+	return cond
+}
+
+// function of operator statement is stored under operator symbol
+func (e *Evaluator) evalCustomOperator(
+	node *ast.InfixExpression,
+	left environment.Object,
+	right environment.Object,
+) environment.Object {
+	function, ok := e.env.Get(node.Operator)
+	if !ok {
+		lib.Die(
+			node.Token,
+			"undefined operator: %s",
+			node.Operator,
+		)
+	}
+
+	result, err := environment.MetaCall(function, "__call", e, nil, left, right)
 	if err != nil {
 		lib.Die(
 			node.Token,
@@ -98,17 +230,9 @@ func (e *Evaluator) evalCallExpression(
 	node *ast.CallExpression,
 ) environment.Object {
 	left := e.Eval(node.Function)
-
-	var self environment.Object
-	if prop, ok := node.Function.(*ast.AttributeExpression); ok {
-		self = e.Eval(prop.Left)
-	} else {
-		self = nil
-	}
-
 	args := e.evalExpressions(node.Arguments)
 
-	result, err := environment.MetaCall(left, "__call", e, self, args...)
+	result, err := environment.MetaCall(left, "__call", e, nil, args...)
 
 	if err != nil {
 		lib.Die(
@@ -123,15 +247,7 @@ func (e *Evaluator) evalCallExpression(
 func (e *Evaluator) evalIfExpression(
 	node *ast.IfExpression,
 ) environment.Object {
-	cond, err := environment.CheckBool(e.Eval(node.If))
-	if err != nil {
-		lib.Die(
-			node.Token,
-			err.Error(),
-		)
-	}
-
-	if cond {
+	if e.condition(e.Eval(node.If), node.Token) {
 		return e.Eval(node.Then)
 	} else {
 		return e.Eval(node.Else)
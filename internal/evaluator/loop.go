@@ -9,26 +9,14 @@ import (
 func (e *Evaluator) evalWhileStatement(
 	node *ast.WhileStatement,
 ) environment.Object {
-	cond, err := environment.CheckBool(e.Eval(node.If))
-	if err != nil {
-		lib.Die(
-			node.Token,
-			err.Error(),
-		)
-	}
+	cond := e.condition(e.Eval(node.If), node.Token)
 
 	var iters float64
 	for cond {
 		e.Eval(node.Loop)
 		iters++
 
-		cond, err = environment.CheckBool(e.Eval(node.If))
-		if err != nil {
-			lib.Die(
-				node.Token,
-				err.Error(),
-			)
-		}
+		cond = e.condition(e.Eval(node.If), node.Token)
 	}
 
 	return environment.NewNumber(iters)
@@ -42,14 +30,7 @@ func (e *Evaluator) evalRepeatStatement(
 		e.Eval(node.Loop)
 		iters++
 
-		cond, err := environment.CheckBool(e.Eval(node.Until))
-		if err != nil {
-			lib.Die(
-				node.Token,
-				err.Error(),
-			)
-		}
-		if !cond {
+		if !e.condition(e.Eval(node.Until), node.Token) {
 			break
 		}
 	}
@@ -79,7 +60,13 @@ func (e *Evaluator) evalForStatement(
 				err.Error(),
 			)
 		}
-		value, cont, err := environment.UnpackResult(next)
+		var key, value environment.Object
+		var cont bool
+		if node.Key != nil {
+			key, value, cont, err = environment.UnpackPair(next)
+		} else {
+			value, cont, err = environment.UnpackResult(next)
+		}
 		if err != nil {
 			lib.Die(
 				node.Token,
@@ -90,10 +77,13 @@ func (e *Evaluator) evalForStatement(
 			break
 		}
 		args := map[string]environment.Object{}
+		if node.Key != nil {
+			args[node.Key.Value] = key
+		}
 		if node.Value != nil {
 			args[node.Value.Value] = value
 		}
-		e.EvalBlock(node.Loop, e.env, args)
+		e.EvalBlock(node.Loop, e.env, args, e.pragmas)
 		iters++
 	}
 
@@ -0,0 +1,233 @@
+package evaluator
+
+import (
+	"fmt"
+	"wildscript/internal/ast"
+	"wildscript/internal/environment"
+	"wildscript/internal/lib"
+)
+
+const maxMacroDepth = 100
+
+// counter for names introduced by quotes
+var gensym int
+
+// replaces calls of top-level macros with code they return,
+// macro bodies are evaluated with builtins only
+func ExpandMacros(program *ast.Program) *ast.Program {
+	macros := map[string]*ast.MacroStatement{}
+	for _, stmt := range program.Statements {
+		if macro, ok := stmt.(*ast.MacroStatement); ok {
+			macros[macro.Name.Value] = macro
+		}
+	}
+	if len(macros) == 0 {
+		return program
+	}
+
+	return expandMacros(program, macros, 0).(*ast.Program)
+}
+
+func expandMacros(
+	node ast.Node,
+	macros map[string]*ast.MacroStatement,
+	depth int,
+) ast.Node {
+	return ast.Modify(node, func(node ast.Node) (ast.Node, bool) {
+		if _, ok := node.(*ast.MacroStatement); ok {
+			return node, false
+		}
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node, true
+		}
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok {
+			return node, true
+		}
+		macro, ok := macros[ident.Value]
+		if !ok {
+			return node, true
+		}
+
+		if depth >= maxMacroDepth {
+			lib.Die(
+				call.Token,
+				"macro %s expands too deep",
+				macro.Name.Value,
+			)
+		}
+		expanded := expandMacro(macro, call)
+		return expandMacros(expanded, macros, depth+1), false
+	})
+}
+
+// arguments are passed unevaluated as quote documents
+func expandMacro(
+	macro *ast.MacroStatement,
+	call *ast.CallExpression,
+) ast.Node {
+	if len(call.Arguments) != len(macro.Parameters) {
+		lib.Die(
+			call.Token,
+			"macro %s wants %d argument(s), got %d",
+			macro.Name.Value,
+			len(macro.Parameters),
+			len(call.Arguments),
+		)
+	}
+
+	args := map[string]environment.Object{}
+	for idx, param := range macro.Parameters {
+		args[param.Value] = environment.NewQuote(call.Arguments[idx])
+	}
+
+	macroEval := New(nil)
+	result := macroEval.EvalBlock(macro.Body, nil, args, macroEval.pragmas)
+	if ret, ok := result.(*environment.Return); ok {
+		result = ret.Value
+	}
+
+	expanded, err := environment.QuotedNode(result, call.Token)
+	if err != nil {
+		lib.Die(
+			call.Token,
+			"macro %s: %s",
+			macro.Name.Value,
+			err.Error(),
+		)
+	}
+	return expanded
+}
+
+// names bound inside quote are renamed so they can not clash with
+// names at expansion site, unquoted code is spliced as is
+func (e *Evaluator) evalQuoteExpression(
+	node *ast.QuoteExpression,
+) environment.Object {
+	body := ast.Clone(node.Body).(*ast.BlockExpression)
+
+	gensym++
+	r := &renamer{suffix: fmt.Sprintf("#%d", gensym)}
+	r.walk(body)
+
+	ast.Modify(body, func(node ast.Node) (ast.Node, bool) {
+		unquote, ok := node.(*ast.UnquoteExpression)
+		if !ok {
+			return node, true
+		}
+		spliced, err := environment.QuotedNode(e.Eval(unquote.Value), unquote.Token)
+		if err != nil {
+			lib.Die(
+				unquote.Token,
+				err.Error(),
+			)
+		}
+		return spliced, false
+	})
+
+	if len(body.Statements) == 1 {
+		if stmt, ok := body.Statements[0].(*ast.ExpressionStatement); ok {
+			return environment.NewQuote(stmt.Expression)
+		}
+	}
+	return environment.NewQuote(body)
+}
+
+// renames names bound inside quote within their scope only,
+// free names and unquoted code are left as is
+type renamer struct {
+	suffix string
+	scopes []map[string]string
+}
+
+func (r *renamer) walk(node ast.Node) {
+	ast.Modify(node, r.visit)
+}
+
+func (r *renamer) visit(node ast.Node) (ast.Node, bool) {
+	switch node := node.(type) {
+	case *ast.UnquoteExpression:
+		return node, false
+	case *ast.Identifier:
+		r.rename(node)
+	case *ast.BlockExpression:
+		r.scoped(func() {
+			for _, stmt := range node.Statements {
+				r.walk(stmt)
+			}
+		})
+		return node, false
+	case *ast.LetStatement:
+		r.walk(node.Right) // sees name of outer scope
+		r.bind(node.Left)
+		return node, false
+	case *ast.FunctionStatement:
+		r.bind(node.Identifier) // visible in its own body
+		r.walk(node.Function)
+		return node, false
+	case *ast.FunctionLiteral:
+		r.scoped(func() {
+			for _, param := range node.Parameters {
+				r.bind(param)
+			}
+			r.walk(node.Body)
+		})
+		return node, false
+	case *ast.ForStatement:
+		r.walk(node.Iterable)
+		r.scoped(func() {
+			r.bind(node.Key)
+			r.bind(node.Value)
+			r.walk(node.Loop)
+		})
+		return node, false
+	case *ast.WithStatement:
+		r.walk(node.Resource)
+		r.scoped(func() {
+			r.bind(node.Name)
+			r.walk(node.Body)
+		})
+		return node, false
+	case *ast.EnumStatement:
+		for _, member := range node.Members {
+			if member.Value != nil {
+				r.walk(member.Value)
+			}
+		}
+		r.bind(node.Name)
+		return node, false
+	case *ast.ProtocolStatement:
+		r.bind(node.Name)
+		return node, false
+	}
+	return node, true
+}
+
+func (r *renamer) scoped(walk func()) {
+	r.scopes = append(r.scopes, map[string]string{})
+	walk()
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// name is renamed from here to the end of current scope
+func (r *renamer) bind(ident *ast.Identifier) {
+	if ident == nil {
+		return
+	}
+	renamed := ident.Value + r.suffix
+	r.scopes[len(r.scopes)-1][ident.Value] = renamed
+	ident.Value = renamed
+}
+
+func (r *renamer) rename(ident *ast.Identifier) {
+	if ident == nil {
+		return
+	}
+	for idx := len(r.scopes) - 1; idx >= 0; idx-- {
+		if renamed, ok := r.scopes[idx][ident.Value]; ok {
+			ident.Value = renamed
+			return
+		}
+	}
+}
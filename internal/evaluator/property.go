@@ -22,6 +22,12 @@ func (e *Evaluator) evalAttributeExpression(
 	return result
 }
 
+// number or nil for omitted index
+func isIndex(object environment.Object) bool {
+	return object.Type() == environment.NUMBER ||
+		object.Type() == environment.NIL
+}
+
 func (e *Evaluator) evalIndexExpression(
 	node *ast.IndexExpression,
 ) environment.Object {
@@ -65,18 +71,16 @@ func (e *Evaluator) evalSliceExpression(
 	left := e.Eval(node.Left)
 	start := e.Eval(node.Start)
 	end := e.Eval(node.End)
+	step := e.Eval(node.Step)
 
-	if (start.Type() != environment.NUMBER &&
-		start.Type() != environment.NIL) ||
-		(end.Type() != environment.NUMBER &&
-			end.Type() != environment.NIL) {
+	if !isIndex(start) || !isIndex(end) || !isIndex(step) {
 		lib.Die(
 			node.Token,
 			"non num index",
 		)
 	}
 
-	result, err := environment.MetaCall(left, "__slice", e, nil, start, end)
+	result, err := environment.MetaCall(left, "__slice", e, nil, start, end, step)
 	if err != nil {
 		lib.Die(
 			node.Token,
@@ -93,7 +97,7 @@ func (e *Evaluator) evalKeyExpression(
 	left := e.Eval(node.Left)
 	key := e.Eval(node.Key)
 
-	if key.Type() == environment.NIL {
+	if node.Whole() {
 		result, err := environment.MetaCall(left, "__dict", e, nil)
 		if err != nil {
 			lib.Die(
@@ -0,0 +1,51 @@
+package evaluator
+
+import (
+	"fmt"
+	"wildscript/internal/ast"
+	"wildscript/internal/environment"
+	"wildscript/internal/lib"
+)
+
+// __exit gets error document or nil and is called
+// even if block is left by panic or signal
+func (e *Evaluator) evalWithStatement(
+	node *ast.WithStatement,
+) environment.Object {
+	resource := e.Eval(node.Resource)
+
+	value, err := environment.MetaCall(resource, "__enter", e, nil)
+	if err != nil {
+		lib.Die(
+			node.Token,
+			err.Error(),
+		)
+	}
+
+	args := map[string]environment.Object{}
+	if node.Name != nil {
+		args[node.Name.Value] = value
+	}
+
+	defer func() {
+		var reason environment.Object = environment.NewNil()
+		p := recover()
+		if p != nil {
+			reason = environment.NewError(fmt.Sprint(p))
+		}
+
+		_, err := environment.MetaCall(resource, "__exit", e, nil, reason)
+		if err != nil {
+			lib.Die(
+				node.Token,
+				err.Error(),
+			)
+		}
+
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	return e.EvalBlock(node.Body, e.env, args, e.pragmas)
+}
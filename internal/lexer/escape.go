@@ -0,0 +1,162 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodes escape sequences of raw string content which starts at line and column,
+// first invalid escape is returned as illegal token
+func unescape(raw string, line, column int) (string, *Token) {
+	var sb strings.Builder
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			r, size := utf8.DecodeRuneInString(raw[i:])
+			sb.WriteString(raw[i : i+size])
+			line, column = advance(r, line, column)
+			i += size
+			continue
+		}
+
+		r, size, ok := decodeEscape(raw[i:])
+		if !ok {
+			token := newToken(ILLEGAL, raw[i:i+size], line, column)
+			return "", &token
+		}
+		sb.WriteRune(r)
+		for _, ch := range raw[i : i+size] {
+			line, column = advance(ch, line, column)
+		}
+		i += size
+	}
+	return sb.String(), nil
+}
+
+type textLine struct {
+	text   string
+	line   int
+	column int
+}
+
+// drops blank first and last lines, strips common indentation
+// and decodes escapes of multiline string content
+func trimIndent(raw string, line, column int) (string, *Token) {
+	var lines []textLine
+	for idx, text := range strings.Split(raw, "\n") {
+		lines = append(lines, textLine{
+			text:   strings.TrimSuffix(text, "\r"),
+			line:   line + idx,
+			column: 1,
+		})
+	}
+	lines[0].column = column
+
+	if len(lines) > 1 && isBlank(lines[0].text) {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && isBlank(lines[len(lines)-1].text) {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, tl := range lines {
+		if isBlank(tl.text) {
+			continue
+		}
+		if n := indentOf(tl.text); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	indent = max(indent, 0)
+
+	var sb strings.Builder
+	for idx, tl := range lines {
+		cut := min(indent, indentOf(tl.text))
+		text, illegal := unescape(tl.text[cut:], tl.line, tl.column+cut)
+		if illegal != nil {
+			return "", illegal
+		}
+		sb.WriteString(text)
+		if idx != len(lines)-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+func isBlank(s string) bool {
+	return strings.TrimLeft(s, " \t") == ""
+}
+
+func indentOf(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+func advance(ch rune, line, column int) (int, int) {
+	if ch == '\n' {
+		return line + 1, 1
+	}
+	return line, column + 1
+}
+
+// s starts with \, returns decoded rune and length of escape sequence
+func decodeEscape(s string) (rune, int, bool) {
+	if len(s) < 2 {
+		return 0, len(s), false
+	}
+	switch s[1] {
+	case '\\':
+		return '\\', 2, true
+	case '"':
+		return '"', 2, true
+	case 'n':
+		return '\n', 2, true
+	case 't':
+		return '\t', 2, true
+	case 'r':
+		return '\r', 2, true
+	case '0':
+		return 0, 2, true
+	case 'x':
+		size := 2
+		for size < 4 && size < len(s) && isHexDigit(rune(s[size])) {
+			size++
+		}
+		if size != 4 {
+			return 0, size, false
+		}
+		code, _ := strconv.ParseUint(s[2:4], 16, 8)
+		return rune(code), size, true
+	case 'u':
+		if len(s) < 3 || s[2] != '{' {
+			return 0, 2, false
+		}
+		size := 3
+		for size < len(s) && isHexDigit(rune(s[size])) {
+			size++
+		}
+		if size >= len(s) || s[size] != '}' {
+			return 0, size, false
+		}
+		size++ // include }
+		digits := s[3 : size-1]
+		if len(digits) == 0 || len(digits) > 6 {
+			return 0, size, false
+		}
+		code, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(code)) {
+			return 0, size, false
+		}
+		return rune(code), size, true
+	default:
+		_, size := utf8.DecodeRuneInString(s[1:])
+		return 0, 1 + size, false
+	}
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) ||
+		('a' <= c && c <= 'f') ||
+		('A' <= c && c <= 'F')
+}
@@ -1,37 +1,51 @@
 package lexer
 
 import (
+	"bytes"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	input   []byte
 	pos     int
 	readPos int
-	ch      byte
+	ch      rune
 	line    int
 	column  int
+	newLine bool
+
+	// symbols declared by operator statements
+	operators map[string]TokenType
+	declaring bool
+
+	// directives are allowed only before first token
+	started bool
 }
 
 func New(input []byte) *Lexer {
 	l := &Lexer{
-		input:  input,
-		line:   1,
-		column: 0,
+		input:     input,
+		line:      1,
+		column:    0,
+		operators: map[string]TokenType{},
 	}
 	l.readChar()
 	return l
 }
 
+// decodes next utf-8 character, column counts characters
 func (l *Lexer) readChar() {
+	size := 1
 	if l.readPos >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPos]
+		l.ch, size = utf8.DecodeRune(l.input[l.readPos:])
 	}
 
 	l.pos = l.readPos
-	l.readPos++
+	l.readPos += size
 
 	if l.ch == '\n' {
 		l.line++
@@ -41,11 +55,20 @@ func (l *Lexer) readChar() {
 	}
 }
 
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.readPos >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPos]
+	r, _ := utf8.DecodeRune(l.input[l.readPos:])
+	return r
+}
+
+// position runs past input when tokens are read after EOF
+func (l *Lexer) startsWith(prefix string) bool {
+	if l.pos >= len(l.input) {
+		return false
+	}
+	return bytes.HasPrefix(l.input[l.pos:], []byte(prefix))
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -53,6 +76,9 @@ func (l *Lexer) skipWhitespace() {
 		l.ch == '\r' ||
 		l.ch == '\n' ||
 		l.ch == '\t' {
+		if l.ch == '\n' {
+			l.newLine = true
+		}
 		l.readChar()
 	}
 }
@@ -64,20 +90,44 @@ func (l *Lexer) skipComment() {
 }
 
 func (l *Lexer) NextToken() Token {
+	token := l.nextToken()
+	token.NewLine = l.newLine
+	l.newLine = false
+	l.declaring = token.Type == OPERATOR
+	l.started = l.started || token.Type != DIRECTIVE
+	return token
+}
+
+func (l *Lexer) nextToken() Token {
 	var token Token
 
 	l.skipWhitespace()
 
-	if t, ok := dual[string([]byte{l.ch, l.peekChar()})]; ok {
+	if l.declaring && isSymbol(l.ch) {
+		return l.readOperator()
+	} else if t, literal := l.matchOperator(); t != "" {
+		token = newToken(t, literal, l.line, l.column)
+		for range len([]rune(literal)) - 1 {
+			l.readChar()
+		}
+	} else if l.ch == '.' && isDigit(l.peekChar()) {
+		return l.readNumber()
+	} else if t, ok := dual[string([]rune{l.ch, l.peekChar()})]; ok {
 		token = newToken(t, string(t), l.line, l.column)
 		l.readChar()
 	} else if t, ok := mono[l.ch]; ok {
 		token = newToken(t, string(l.ch), l.line, l.column)
+	} else if l.startsWith("#!") && !l.started && !l.startsWith("#!/") {
+		return l.readDirective()
 	} else if l.ch == '#' {
 		l.skipComment()
-		return l.NextToken()
+		return l.nextToken()
+	} else if l.startsWith(`"""`) {
+		return l.readMultilineString()
 	} else if l.ch == '"' {
 		return l.readString()
+	} else if l.ch == '`' {
+		return l.readRawString()
 	} else if isDigit(l.ch) {
 		return l.readNumber()
 	} else if isLetter(l.ch) {
@@ -92,50 +142,207 @@ func (l *Lexer) NextToken() Token {
 	return token
 }
 
+// #!edition 2 at the top of file, shebang #!/... is a comment
+func (l *Lexer) readDirective() Token {
+	line, column := l.line, l.column
+	l.readChar()
+	l.readChar()
+	start := l.pos
+	l.skipComment()
+	literal := strings.TrimSpace(string(l.input[start:l.pos]))
+	return newToken(DIRECTIVE, literal, line, column)
+}
+
+// symbol after operator keyword becomes a token for the rest of input,
+// its type is the symbol itself like for built-in operators
+func (l *Lexer) readOperator() Token {
+	line, column := l.line, l.column
+	start := l.pos
+	for isSymbol(l.ch) {
+		l.readChar()
+	}
+	symbol := string(l.input[start:l.pos])
+	if !IsReservedSymbol(symbol) { // parser reports it
+		l.operators[symbol] = TokenType(symbol)
+	}
+	return newToken(TokenType(symbol), symbol, line, column)
+}
+
+// symbols of grammar can not be declared as operators,
+// neither can symbols containing punctuation,
+// start of built-in symbol like ! of != which would shadow it
+// and built-in symbol followed by unary minus like <- of x<-1
+func IsReservedSymbol(symbol string) bool {
+	if isBuiltinSymbol(symbol) || isBuiltinSymbol(strings.TrimSuffix(symbol, "-")) {
+		return true
+	}
+	for builtin := range dual {
+		if strings.HasPrefix(builtin, symbol) {
+			return true
+		}
+	}
+	return strings.ContainsAny(symbol, reservedPunctuation)
+}
+
+func isBuiltinSymbol(symbol string) bool {
+	if _, ok := dual[symbol]; ok {
+		return true
+	}
+	runes := []rune(symbol)
+	if len(runes) != 1 {
+		return false
+	}
+	_, ok := mono[runes[0]]
+	return ok
+}
+
+// longest declared operator at current position
+func (l *Lexer) matchOperator() (TokenType, string) {
+	var result string
+	for symbol := range l.operators {
+		if len(symbol) > len(result) && l.startsWith(symbol) {
+			result = symbol
+		}
+	}
+	return l.operators[result], result
+}
+
 func (l *Lexer) readString() Token {
 	line, column := l.line, l.column
 	l.readChar()
-	var sb strings.Builder
+	start := l.pos
+	contentLine, contentColumn := l.line, l.column
 	for l.ch != '"' {
-		if l.ch == '\\' {
+		if l.ch == 0 {
+			return newToken(ILLEGAL, string(l.input[start:l.pos]), line, column)
+		}
+		if l.ch == '\\' && l.peekChar() != 0 {
 			l.readChar()
-			switch l.ch {
-			case '\\':
-				sb.WriteByte('\\')
-			case '"':
-				sb.WriteByte('"')
-			case 'n':
-				sb.WriteByte('\n')
-			}
-		} else {
-			sb.WriteByte(l.ch)
 		}
+		l.readChar()
+	}
+	raw := string(l.input[start:l.pos])
+	l.readChar()
+
+	value, illegal := unescape(raw, contentLine, contentColumn)
+	if illegal != nil {
+		return *illegal
+	}
+	return newToken(STRING, value, line, column)
+}
 
-		if l.peekChar() == 0 {
+// content between triple quotes with common indentation stripped
+func (l *Lexer) readMultilineString() Token {
+	line, column := l.line, l.column
+	for range 3 {
+		l.readChar()
+	}
+	start := l.pos
+	// current line is already next one when content starts with new line
+	contentLine, contentColumn := line, column+3
+	for !l.startsWith(`"""`) {
+		if l.ch == 0 {
+			return newToken(ILLEGAL, string(l.input[start-3:l.pos]), line, column)
+		}
+		if l.ch == '\\' && l.peekChar() != 0 {
 			l.readChar()
-			return newToken(ILLEGAL, sb.String(), line, column)
 		}
+		l.readChar()
+	}
+	raw := string(l.input[start:l.pos])
+	for range 3 {
+		l.readChar()
+	}
+
+	value, illegal := trimIndent(raw, contentLine, contentColumn)
+	if illegal != nil {
+		return *illegal
+	}
+	return newToken(STRING, value, line, column)
+}
 
+// content between backticks as is
+func (l *Lexer) readRawString() Token {
+	line, column := l.line, l.column
+	l.readChar()
+	start := l.pos
+	for l.ch != '`' {
+		if l.ch == 0 {
+			return newToken(ILLEGAL, string(l.input[start-1:l.pos]), line, column)
+		}
 		l.readChar()
 	}
+	raw := string(l.input[start:l.pos])
 	l.readChar()
-	return newToken(STRING, sb.String(), line, column)
+	return newToken(STRING, raw, line, column)
 }
 
+// decimal with optional fraction and exponent or 0x, 0b, 0o integer,
+// digits may be separated by single underscores
 func (l *Lexer) readNumber() Token {
 	line, column := l.line, l.column
 	start := l.pos
-	var dots int
-	for isDigit(l.ch) || l.ch == '.' {
-		if l.ch == '.' {
-			dots++
+	valid := true
+
+	if base := l.peekChar() | 0x20; l.ch == '0' &&
+		(base == 'x' || base == 'b' || base == 'o') {
+		l.readChar()
+		l.readChar()
+		switch base {
+		case 'x':
+			valid = l.readDigits(isHexDigit)
+		case 'b':
+			valid = l.readDigits(isBinDigit)
+		case 'o':
+			valid = l.readDigits(isOctDigit)
+		}
+	} else {
+		if l.ch != '.' {
+			valid = l.readDigits(isDigit)
+		}
+		if l.ch == '.' && isDigit(l.peekChar()) {
+			l.readChar()
+			valid = l.readDigits(isDigit) && valid
+		} else if l.ch == '.' && !isLetter(l.peekChar()) {
+			l.readChar()
+			valid = false
 		}
+		if l.ch == 'e' || l.ch == 'E' {
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			valid = l.readDigits(isDigit) && valid
+		}
+	}
+
+	// 12abc, 0b102, 1.5.5
+	for isLetter(l.ch) || isDigit(l.ch) ||
+		(l.ch == '.' && isDigit(l.peekChar())) {
+		valid = false
 		l.readChar()
 	}
-	if dots > 1 {
-		return newToken(ILLEGAL, string(l.input[start:l.pos]), line, column)
+
+	literal := string(l.input[start:l.pos])
+	if !valid {
+		return newToken(ILLEGAL, literal, line, column)
 	}
-	return newToken(NUMBER, string(l.input[start:l.pos]), line, column)
+	return newToken(NUMBER, literal, line, column)
+}
+
+// at least one digit, underscore only between digits
+func (l *Lexer) readDigits(isDigit func(rune) bool) bool {
+	if !isDigit(l.ch) {
+		return false
+	}
+	valid := true
+	for isDigit(l.ch) || l.ch == '_' {
+		if l.ch == '_' && !isDigit(l.peekChar()) {
+			valid = false
+		}
+		l.readChar()
+	}
+	return valid
 }
 
 func (l *Lexer) readIdentifier() Token {
@@ -156,12 +363,32 @@ func lookupIdent(ident string) TokenType {
 	return IDENTIFIER
 }
 
-func isDigit(c byte) bool {
+// reports whether s is read back as identifier, not keyword
+func IsIdentifier(s string) bool {
+	for idx, c := range s {
+		if !isLetter(c) && (idx == 0 || !isDigit(c)) {
+			return false
+		}
+	}
+	return s != "" && lookupIdent(s) == IDENTIFIER
+}
+
+func isDigit(c rune) bool {
 	return '0' <= c && c <= '9'
 }
 
-func isLetter(c byte) bool {
-	return ('a' <= c && c <= 'z') ||
-		('A' <= c && c <= 'Z') ||
-		c == '_'
+func isSymbol(c rune) bool {
+	return strings.ContainsRune("+-*/%^<>=!&|~$?:.@", c)
+}
+
+func isBinDigit(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+func isOctDigit(c rune) bool {
+	return '0' <= c && c <= '7'
+}
+
+func isLetter(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
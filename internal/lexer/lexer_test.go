@@ -0,0 +1,244 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+)
+
+// tokens of input up to EOF, not included
+func lex(input string) []Token {
+	l := New([]byte(input))
+	var tokens []Token
+	for {
+		token := l.NextToken()
+		if token.Type == EOF {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func TestNewLine(t *testing.T) {
+	tests := []struct {
+		input   string
+		newLine []bool
+	}{
+		{"a b", []bool{false, false}},
+		{"a\nb", []bool{false, true}},
+		{"a\r\n\n  b c", []bool{false, true, false}},
+		{"a # comment\nb", []bool{false, true}},
+		{"\na", []bool{true}},
+		{"a \"x\ny\" b", []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		tokens := lex(tt.input)
+		if len(tokens) != len(tt.newLine) {
+			t.Fatalf("%q: want %d tokens, got %d", tt.input, len(tt.newLine), len(tokens))
+		}
+		for idx, token := range tokens {
+			if token.NewLine != tt.newLine[idx] {
+				t.Errorf("%q: token %q NewLine = %v", tt.input, token.Literal, token.NewLine)
+			}
+		}
+	}
+}
+
+func TestEscapes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TokenType
+		literal string
+	}{
+		{`"a\nb"`, STRING, "a\nb"},
+		{`"\t\r\0\\\""`, STRING, "\t\r\x00\\\""},
+		{`"\x41\x7e"`, STRING, "A~"},
+		{`"\u{44F}\u{1F600}"`, STRING, "я😀"},
+		{`"\q"`, ILLEGAL, `\q`},
+		{`"\x4"`, ILLEGAL, `\x4`},
+		{`"\u{110000}"`, ILLEGAL, `\u{110000}`},
+		{`"open`, ILLEGAL, "open"},
+	}
+
+	for _, tt := range tests {
+		token := lex(tt.input)[0]
+		if token.Type != tt.want || token.Literal != tt.literal {
+			t.Errorf("%s: want %s %q, got %s %q", tt.input, tt.want, tt.literal, token.Type, token.Literal)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TokenType
+		literal string
+	}{
+		{"1176", NUMBER, "1176"},
+		{"10.76", NUMBER, "10.76"},
+		{".5", NUMBER, ".5"},
+		{"1e6", NUMBER, "1e6"},
+		{"1.5E-3", NUMBER, "1.5E-3"},
+		{"2e+2", NUMBER, "2e+2"},
+		{"0xFF", NUMBER, "0xFF"},
+		{"0XfF", NUMBER, "0XfF"},
+		{"0b1010", NUMBER, "0b1010"},
+		{"0o17", NUMBER, "0o17"},
+		{"1_000_000", NUMBER, "1_000_000"},
+		{"1__0", ILLEGAL, "1__0"},
+		{"1_", ILLEGAL, "1_"},
+		{"0x", ILLEGAL, "0x"},
+		{"0b102", ILLEGAL, "0b102"},
+		{"0o8", ILLEGAL, "0o8"},
+		{"1e", ILLEGAL, "1e"},
+		{"12abc", ILLEGAL, "12abc"},
+		{"1.5.5", ILLEGAL, "1.5.5"},
+	}
+
+	for _, tt := range tests {
+		token := lex(tt.input)[0]
+		if token.Type != tt.want || token.Literal != tt.literal {
+			t.Errorf("%s: want %s %q, got %s %q", tt.input, tt.want, tt.literal, token.Type, token.Literal)
+		}
+	}
+
+	// method call on number is not a fraction
+	tokens := lex("1.abs")
+	if len(tokens) != 3 || tokens[0].Literal != "1" || tokens[1].Type != DOT {
+		t.Errorf("1.abs: got %v", tokens)
+	}
+}
+
+func TestRawAndMultilineStrings(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TokenType
+		literal string
+		line    int
+		column  int
+	}{
+		{"`a\\n\"b`", STRING, `a\n"b`, 1, 1},
+		{"x `a\nb`", STRING, "a\nb", 1, 3},
+		{"\"\"\"\n    a\n      b\n    \"\"\"", STRING, "a\n  b", 1, 1},
+		{"\"\"\"one line\"\"\"", STRING, "one line", 1, 1},
+		{"\"\"\"\n  \"q\" \\t\n  \"\"\"", STRING, "\"q\" \t", 1, 1},
+		{"\n  \"\"\"\n  a\n\n  b\n  \"\"\"", STRING, "a\n\nb", 2, 3},
+		{"`open", ILLEGAL, "`open", 1, 1},
+		{"\"\"\"open\n", ILLEGAL, "\"\"\"open\n", 1, 1},
+		// escape error points into content
+		{"\"\"\"\n  a\n  \\q\n\"\"\"", ILLEGAL, `\q`, 3, 3},
+	}
+
+	for _, tt := range tests {
+		tokens := lex(tt.input)
+		token := tokens[len(tokens)-1]
+		if token.Type != tt.want || token.Literal != tt.literal ||
+			token.Line != tt.line || token.Column != tt.column {
+			t.Errorf("%q: want %s %q at %d:%d, got %s %q at %d:%d", tt.input,
+				tt.want, tt.literal, tt.line, tt.column, token.Type, token.Literal, token.Line, token.Column)
+		}
+	}
+
+	// position after multi-line string
+	tokens := lex("\"\"\"\n  a\n  \"\"\" x")
+	if x := tokens[len(tokens)-1]; x.Literal != "x" || x.Line != 3 || x.Column != 7 {
+		t.Errorf("want x at 3:7, got %q at %d:%d", x.Literal, x.Line, x.Column)
+	}
+}
+
+func TestUTF8(t *testing.T) {
+	tokens := lex("let привет = \"мир\"; ñ_1 😀")
+	want := []struct {
+		typ     TokenType
+		literal string
+		column  int
+	}{
+		{LET, "let", 1},
+		{IDENTIFIER, "привет", 5},
+		{ASSIGN, "=", 12},
+		{STRING, "мир", 14},
+		{SEMICOLON, ";", 19},
+		{IDENTIFIER, "ñ_1", 21},
+		{ILLEGAL, "😀", 25},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("want %d tokens, got %v", len(want), tokens)
+	}
+	for idx, w := range want {
+		token := tokens[idx]
+		if token.Type != w.typ || token.Literal != w.literal || token.Column != w.column {
+			t.Errorf("want %s %q at %d, got %s %q at %d",
+				w.typ, w.literal, w.column, token.Type, token.Literal, token.Column)
+		}
+	}
+}
+
+func TestDeclaredOperators(t *testing.T) {
+	tests := []struct {
+		input   string
+		symbols []string
+	}{
+		// symbol is a token only after its declaration
+		{"a <+> b operator <+> a <+> b", []string{"<", "+", ">", "<+>", "<+>"}},
+		// longest declared symbol wins
+		{"operator <+ operator <+> a <+> b <+ c", []string{"<+", "<+>", "<+>", "<+"}},
+		// reserved symbol stays built-in
+		{"operator == a == b", []string{"==", "=="}},
+		// start of built-in symbol does not shadow it
+		{"operator ! 1 != 2", []string{"!", "!="}},
+		{"operator <- x<-1", []string{"<-", "<", "-"}},
+	}
+
+	for _, tt := range tests {
+		var symbols []string
+		for _, token := range lex(tt.input) {
+			if token.Type != IDENTIFIER && token.Type != OPERATOR && token.Type != NUMBER {
+				symbols = append(symbols, token.Literal)
+			}
+		}
+		if strings.Join(symbols, " ") != strings.Join(tt.symbols, " ") {
+			t.Errorf("%q: want %v, got %v", tt.input, tt.symbols, symbols)
+		}
+	}
+}
+
+func TestIsReservedSymbol(t *testing.T) {
+	for symbol, want := range map[string]bool{
+		"<+>": false,
+		"**":  false,
+		"|>":  false,
+		"==":  true,
+		"//":  true,
+		"+":   true,
+		":":   true,
+		"::":  true,
+		"?.":  true,
+		"!":   true,
+		"<-":  true,
+		"*-":  true,
+		"--":  true,
+		"=<":  false,
+		"-<":  false,
+	} {
+		if IsReservedSymbol(symbol) != want {
+			t.Errorf("IsReservedSymbol(%q) = %v", symbol, !want)
+		}
+	}
+}
+
+func TestIsIdentifier(t *testing.T) {
+	for s, want := range map[string]bool{
+		"name":   true,
+		"_x1":    true,
+		"имя":    true,
+		"1x":     false,
+		"a-b":    false,
+		"":       false,
+		"let":    false,
+		"lambda": false,
+	} {
+		if IsIdentifier(s) != want {
+			t.Errorf("IsIdentifier(%q) = %v", s, !want)
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package lexer
 
+import "fmt"
+
 type TokenType string
 
 const (
 	ILLEGAL TokenType = "ILLEGAL"
 	EOF     TokenType = "EOF"
 
+	DIRECTIVE TokenType = "DIRECTIVE"
+	PRAGMA    TokenType = "PRAGMA"
+
 	IDENTIFIER TokenType = "IDENTIFIER"
 	LET        TokenType = "LET"
 
@@ -15,6 +20,7 @@ const (
 	FUNCTION TokenType = "FUNCTION"
 	LAMBDA   TokenType = "LAMBDA"
 	METHOD   TokenType = "METHOD"
+	STATIC   TokenType = "STATIC"
 
 	IF   TokenType = "IF"
 	ELIF TokenType = "ELIF"
@@ -32,9 +38,23 @@ const (
 	CONTINUE TokenType = "CONTINUE"
 	BREAK    TokenType = "BREAK"
 
+	DEL TokenType = "DEL"
+
 	IMPORT TokenType = "IMPORT"
 	EXPORT TokenType = "EXPORT"
 
+	WITH TokenType = "WITH"
+	AS   TokenType = "AS"
+
+	ENUM     TokenType = "ENUM"
+	PROTOCOL TokenType = "PROTOCOL"
+
+	OPERATOR TokenType = "OPERATOR"
+
+	MACRO   TokenType = "MACRO"
+	QUOTE   TokenType = "QUOTE"
+	UNQUOTE TokenType = "UNQUOTE"
+
 	AND TokenType = "AND"
 	OR  TokenType = "OR"
 	NOT TokenType = "NOT"
@@ -83,13 +103,14 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+	NewLine bool // token is the first on its line
 }
 
 func newToken(t TokenType, lit string, line, column int) Token {
 	return Token{Type: t, Literal: lit, Line: line, Column: column}
 }
 
-var mono = map[byte]TokenType{
+var mono = map[rune]TokenType{
 	'.': DOT,
 	'@': DOG,
 	'&': AMPER,
@@ -116,6 +137,9 @@ var mono = map[byte]TokenType{
 	'>': GREATER,
 }
 
+// parts of grammar inside operator symbols
+const reservedPunctuation = ".,:;?@$"
+
 var dual = map[string]TokenType{
 	"//": INT_DIVIDE,
 
@@ -131,6 +155,7 @@ var specialIdents = map[string]TokenType{
 	"function": FUNCTION,
 	"lambda":   LAMBDA,
 	"method":   METHOD,
+	"static":   STATIC,
 
 	"if":   IF,
 	"elif": ELIF,
@@ -148,9 +173,25 @@ var specialIdents = map[string]TokenType{
 	"continue": CONTINUE,
 	"break":    BREAK,
 
+	"del": DEL,
+
 	"import": IMPORT,
 	"export": EXPORT,
 
+	"with": WITH,
+	"as":   AS,
+
+	"enum":     ENUM,
+	"protocol": PROTOCOL,
+
+	"operator": OPERATOR,
+
+	"pragma": PRAGMA,
+
+	"macro":   MACRO,
+	"quote":   QUOTE,
+	"unquote": UNQUOTE,
+
 	"and": AND,
 	"or":  OR,
 	"not": NOT,
@@ -160,3 +201,22 @@ var specialIdents = map[string]TokenType{
 
 	"nil": NIL,
 }
+
+// adds one or two character operator token,
+// affects lexers reading after registration
+func RegisterSymbol(symbol string, t TokenType) {
+	runes := []rune(symbol)
+	switch len(runes) {
+	case 1:
+		mono[runes[0]] = t
+	case 2:
+		dual[symbol] = t
+	default:
+		panic(fmt.Sprintf("symbol %q must be one or two characters", symbol))
+	}
+}
+
+// adds keyword token, affects lexers reading after registration
+func RegisterKeyword(ident string, t TokenType) {
+	specialIdents[ident] = t
+}
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"container/list"
+	"iter"
+)
+
+type pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// map remembering insertion order, setting existing key keeps its place
+type OrderedMap[K comparable, V any] struct {
+	index map[K]*list.Element
+	order *list.List
+}
+
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		index: map[K]*list.Element{},
+		order: list.New(),
+	}
+}
+
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.index)
+}
+
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if elem, ok := m.index[key]; ok {
+		return elem.Value.(*pair[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// value of key or zero value
+func (m *OrderedMap[K, V]) At(key K) V {
+	value, _ := m.Get(key)
+	return value
+}
+
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if elem, ok := m.index[key]; ok {
+		elem.Value.(*pair[K, V]).value = value
+		return
+	}
+	m.index[key] = m.order.PushBack(&pair[K, V]{key, value})
+}
+
+// reports whether key existed
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	elem, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.order.Remove(elem)
+	delete(m.index, key)
+	return true
+}
+
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for elem := m.order.Front(); elem != nil; elem = elem.Next() {
+			p := elem.Value.(*pair[K, V])
+			if !yield(p.key, p.value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (m *OrderedMap[K, V]) Clone() *OrderedMap[K, V] {
+	clone := NewOrderedMap[K, V]()
+	for key, value := range m.All() {
+		clone.Set(key, value)
+	}
+	return clone
+}
+
+// every entry of other is set in m in order of other
+func (m *OrderedMap[K, V]) Merge(other *OrderedMap[K, V]) {
+	for key, value := range other.All() {
+		m.Set(key, value)
+	}
+}
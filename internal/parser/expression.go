@@ -2,90 +2,80 @@ package parser
 
 import (
 	"strconv"
+	"strings"
 	"wildscript/internal/ast"
 	"wildscript/internal/lexer"
 )
 
 // not include ; or EOF
+// stops before line break if next line can start a new statement
 func (p *Parser) parseExpression(precedence int) ast.Expression {
-	var expr ast.Expression
-
-	switch p.curToken.Type {
-	case lexer.NOT:
-		expr = p.parsePrefixExpression()
-	case lexer.MINUS:
-		expr = p.parsePrefixExpression()
-	case lexer.LPAREN:
-		p.nextToken() // to expr
-		expr = p.parseExpression(LOWEST)
-		if p.peekToken.Type != lexer.RPAREN {
-			die(p.peekToken, "expected )")
-		}
-		p.nextToken() // to )
-
-	case lexer.IDENTIFIER:
-		expr = p.parseIdentifier()
-
-	case lexer.IF:
-		expr = p.parseIfExpression()
-	case lexer.ELIF:
-		expr = p.parseIfExpression()
-
-	case lexer.LAMBDA:
-		p.nextToken() // to (
-		expr = p.parseFunctionLiteral(ast.LAMBDA)
-	case lexer.METHOD:
-		p.nextToken() // to (
-		expr = p.parseFunctionLiteral(ast.METHOD)
-	case lexer.LBRACE:
-		expr = p.parseDocumentLiteral()
-	case lexer.NUMBER:
-		value, err := strconv.ParseFloat(p.curToken.Literal, 64)
-		if err != nil {
-			die(
-				p.curToken,
-				"could not parse %s as number",
-				p.curToken.Literal,
-			)
-		}
-		expr = &ast.NumberLiteral{Token: p.curToken, Value: value}
-	case lexer.STRING:
-		expr = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
-	case lexer.TRUE:
-		expr = &ast.BooleanLiteral{Token: p.curToken, Value: true}
-	case lexer.FALSE:
-		expr = &ast.BooleanLiteral{Token: p.curToken, Value: false}
-	case lexer.NIL:
-		expr = &ast.NilLiteral{Token: p.curToken}
-	default:
+	prefix, ok := p.prefixParseFns[p.curToken.Type]
+	if !ok {
 		die(
 			p.curToken,
 			"unexpected token: %s",
 			p.curToken.Literal,
 		)
 	}
+	expr := prefix(p)
 
-	for precedence < p.peekPrecedence() {
+	for precedence < p.peekPrecedence() && !p.peekStartsLine() {
+		infix, ok := p.infixParseFns[p.peekToken.Type]
+		if !ok {
+			break
+		}
 
 		p.nextToken() // to op
-
-		switch p.curToken.Type {
-		case lexer.DOT:
-			expr = p.parseAttributeExpression(expr)
-		case lexer.LBRACKET:
-			expr = p.parseBracketExpression(expr)
-		case lexer.LBRACE:
-			expr = p.parseKeyExpression(expr)
-		case lexer.LPAREN:
-			expr = p.parseCallExpression(expr)
-		default:
-			expr = p.parseInfixExpression(expr)
-		}
+		expr = infix(p, expr)
 	}
 
 	return expr
 }
 
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.nextToken() // to expr
+	expr := p.parseExpression(LOWEST)
+	if p.peekToken.Type != lexer.RPAREN {
+		die(p.peekToken, "expected )")
+	}
+	p.nextToken() // to )
+	return expr
+}
+
+func (p *Parser) parseNumberLiteral() *ast.NumberLiteral {
+	value, err := parseNumber(p.curToken.Literal)
+	if err != nil {
+		die(
+			p.curToken,
+			"could not parse %s as number",
+			p.curToken.Literal,
+		)
+	}
+	return &ast.NumberLiteral{Token: p.curToken, Value: value}
+}
+
+// decimal float or 0x, 0b, 0o integer with _ separators
+func parseNumber(literal string) (float64, error) {
+	literal = strings.ReplaceAll(literal, "_", "")
+	if len(literal) > 2 && literal[0] == '0' {
+		base := 0
+		switch literal[1] | 0x20 {
+		case 'x':
+			base = 16
+		case 'b':
+			base = 2
+		case 'o':
+			base = 8
+		}
+		if base != 0 {
+			value, err := strconv.ParseUint(literal[2:], base, 64)
+			return float64(value), err
+		}
+	}
+	return strconv.ParseFloat(literal, 64)
+}
+
 func (p *Parser) parseIdentifier() *ast.Identifier {
 	expr := &ast.Identifier{
 		Token: p.curToken,
@@ -190,17 +180,27 @@ func (p *Parser) parseBracketExpression(
 	if p.peekToken.Type == lexer.COLON {
 		p.nextToken() // to :
 		var secondIndex ast.Expression
-		if p.peekToken.Type == lexer.RBRACKET {
+		if p.peekToken.Type == lexer.COLON ||
+			p.peekToken.Type == lexer.RBRACKET {
 			secondIndex = &ast.NilLiteral{Token: p.curToken}
 		} else {
 			p.nextToken() // to expr
 			secondIndex = p.parseExpression(LOWEST)
 		}
+		var step ast.Expression = &ast.NilLiteral{Token: p.peekToken}
+		if p.peekToken.Type == lexer.COLON {
+			p.nextToken() // to :
+			if p.peekToken.Type != lexer.RBRACKET {
+				p.nextToken() // to expr
+				step = p.parseExpression(LOWEST)
+			}
+		}
 		expr = &ast.SliceExpression{
 			Token: token,
 			Left:  left,
 			Start: firstIndex,
 			End:   secondIndex,
+			Step:  step,
 		}
 	} else {
 		expr = &ast.IndexExpression{
@@ -338,6 +338,8 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 func (p *Parser) parseBlockExpression() *ast.BlockExpression {
 	block := &ast.BlockExpression{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+	p.depth++
+	defer func() { p.depth-- }()
 
 	p.nextToken() // to statement
 
@@ -416,3 +418,32 @@ func (p *Parser) parseDocumentElement() *ast.DocumentElement {
 
 	return elem
 }
+
+func (p *Parser) parseQuoteExpression() *ast.QuoteExpression {
+	expr := &ast.QuoteExpression{
+		Token: p.curToken,
+	}
+	if p.peekToken.Type != lexer.LBRACE {
+		p.expected("{")
+	}
+	p.nextToken() // to {
+	expr.Body = p.parseBlockExpression()
+	return expr
+}
+
+func (p *Parser) parseUnquoteExpression() *ast.UnquoteExpression {
+	expr := &ast.UnquoteExpression{
+		Token: p.curToken,
+	}
+	if p.peekToken.Type != lexer.LPAREN {
+		p.expected("(")
+	}
+	p.nextToken() // to (
+	p.nextToken() // to expr
+	expr.Value = p.parseExpression(LOWEST)
+	if p.peekToken.Type != lexer.RPAREN {
+		p.expected(")")
+	}
+	p.nextToken() // to )
+	return expr
+}
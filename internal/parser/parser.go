@@ -15,10 +15,21 @@ type Parser struct {
 	lexer     Tokenizer
 	curToken  lexer.Token
 	peekToken lexer.Token
+
+	prefixParseFns map[lexer.TokenType]PrefixParseFn
+	infixParseFns  map[lexer.TokenType]InfixParseFn
+	precedences    map[lexer.TokenType]int
+
+	// new line ends statement, set by pragmas of program
+	asi bool
+
+	// nesting of blocks, zero at module level
+	depth int
 }
 
 func New(lexer Tokenizer) *Parser {
-	p := &Parser{lexer: lexer}
+	p := &Parser{lexer: lexer, asi: true}
+	p.loadRegistry()
 	p.nextToken() // fill peek
 	p.nextToken() // fill cur
 	return p
@@ -34,6 +45,9 @@ func (p *Parser) ParseProgram() *ast.Program {
 		Token: p.curToken,
 	}
 
+	program.Pragmas = p.parsePragmas()
+	p.asi = program.Pragmas.ASI
+
 	for {
 		stmt := p.parseStatement() // include ; or EOF
 		program.Statements = append(program.Statements, stmt)
@@ -49,19 +63,43 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 func (p *Parser) peekPrecedence() int {
-	if precedence, ok := precedences[p.peekToken.Type]; ok {
+	if precedence, ok := p.precedences[p.peekToken.Type]; ok {
 		return precedence
 	}
 	return LOWEST
 }
 
 func (p *Parser) curPrecedence() int {
-	if precedence, ok := precedences[p.curToken.Type]; ok {
+	if precedence, ok := p.precedences[p.curToken.Type]; ok {
 		return precedence
 	}
 	return LOWEST
 }
 
+// newline before token which can start an expression ends the statement
+// instead of continuing the expression
+func (p *Parser) peekStartsLine() bool {
+	_, ok := p.prefixParseFns[p.peekToken.Type]
+	return p.asi && p.peekToken.NewLine && ok
+}
+
+func (p *Parser) peekEndsStatement() bool {
+	return p.peekToken.Type == lexer.SEMICOLON ||
+		p.peekToken.Type == lexer.EOF ||
+		p.peekToken.Type == lexer.RBRACE ||
+		(p.asi && p.peekToken.NewLine)
+}
+
+// newline works as implicit ;
+func (p *Parser) insertSemicolon() {
+	p.curToken = lexer.Token{
+		Type:    lexer.SEMICOLON,
+		Literal: ";",
+		Line:    p.curToken.Line,
+		Column:  p.curToken.Column,
+	}
+}
+
 func die(token lexer.Token, text string, args ...any) {
 	text = fmt.Sprintf("[parser] %s", text)
 	lib.Die(token, text, args...)
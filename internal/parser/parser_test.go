@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"wildscript/internal/ast"
+	"wildscript/internal/lexer"
+)
+
+// program printed back or message of parser panic
+func parse(input string) (program string, err string) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Sprint(p)
+		}
+	}()
+	p := New(lexer.New([]byte(input)))
+	return p.ParseProgram().String(), ""
+}
+
+type parserTest struct {
+	input string
+	want  string // program printed back
+	err   string // part of expected error, empty when parsing must succeed
+}
+
+func runParserTests(t *testing.T, tests []parserTest) {
+	t.Helper()
+	for _, tt := range tests {
+		program, err := parse(tt.input)
+		switch {
+		case tt.err != "" && !strings.Contains(err, tt.err):
+			t.Errorf("%q: want error %q, got %q (program %q)", tt.input, tt.err, err, program)
+		case tt.err == "" && err != "":
+			t.Errorf("%q: unexpected error %s", tt.input, err)
+		case tt.err == "" && program != tt.want:
+			t.Errorf("%q: want %q, got %q", tt.input, tt.want, program)
+		}
+	}
+}
+
+func TestASI(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"let a = 1\nlet b = 2", "let a = 1\nlet b = 2", ""},
+		{"let a = 1; let b = 2", "let a = 1\nlet b = 2", ""},
+		{"let a = 1 +\n2", "let a = (1 + 2)", ""},
+		{"f(1,\n2)\ng()", "f(1, 2)\ng()", ""},
+		{"let a = 1 let b = 2", "", "expected ; or } or new line"},
+		{"pragma no_asi\nlet a = 1\n+ 2; b", "let a = (1 + 2)\nb", ""},
+	})
+}
+
+func TestNumberLiterals(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"0xFF; 0b11; 0o17", "255\n3\n15", ""},
+		{"1_000 + .5", "(1000 + 0.5)", ""},
+		{"2.5e2", "250", ""},
+	})
+}
+
+func TestPrecedence(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"1 + 2 * 3", "(1 + (2 * 3))", ""},
+		{"(1 + 2) * 3", "((1 + 2) * 3)", ""},
+		{"1 - 2 - 3", "((1 - 2) - 3)", ""},
+		{"-a ^ 2", "(- (a ^ 2))", ""},
+		{"a // b % c", "((a // b) % c)", ""},
+		{"1 < 2 == true", "((1 < 2) == true)", ""},
+		{"x or y and not z", "(x or (y and (not z)))", ""},
+		{"a + b.c(1)[2]{3}", "(a + b.c(1)[2]{3})", ""},
+	})
+}
+
+// parser registry is copied on creation, parser may extend its own copy
+func TestRegisterInfix(t *testing.T) {
+	p := New(lexer.New([]byte("a & b + c")))
+	p.RegisterInfix(lexer.AMPER, LOGICAL_AND, func(p *Parser, left ast.Expression) ast.Expression {
+		return p.parseInfixExpression(left)
+	})
+	if got := p.ParseProgram().String(); got != "(a & (b + c))" {
+		t.Errorf("want (a & (b + c)), got %q", got)
+	}
+
+	if _, err := parse("a & b"); err == "" {
+		t.Error("registration leaked into other parser")
+	}
+}
+
+func TestOperatorStatement(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"operator <+> 5 left = f\n1 <+> 2 <+> 3", "operator <+> 5 left = f\n((1 <+> 2) <+> 3)", ""},
+		{"operator ** 8 right = f\n2 ** 3 ** 2", "operator ** 8 right = f\n(2 ** (3 ** 2))", ""},
+		{"operator ** 8 right = f\n1 + 2 ** 3", "operator ** 8 right = f\n(1 + (2 ** 3))", ""},
+		{"operator :: 5 left = f", "", "operator :: is reserved"},
+		{"operator == 5 left = f", "", "operator == is reserved"},
+		{"operator , 5 left = f", "", "operator , is reserved"},
+		{"operator ! 5 left = f\n1 != 2", "", "operator ! is reserved"},
+		{"operator <- 5 left = f\nx<-1", "", "operator <- is reserved"},
+		{"x<-1", "(x < (- 1))", ""},
+		{"f = lambda() { operator <> 5 left = g }", "", "operator must be at the top of module"},
+		{"operator <+> 5 middle = f", "", "expected left or right"},
+		{"operator <+> x left = f", "", "expected precedence"},
+	})
+}
+
+func TestForStatement(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"for v in d do { v }", "for v in d do {v}", ""},
+		{"for k, v in d{} do { k }", "for k, v in d{} do {k}", ""},
+		{"for k, in d do {}", "", "expected identifier"},
+		{"for 1 in d do {}", "", "expected identifier before in"},
+		{"for k, v, w in d do {}", "", "expected in"},
+	})
+}
+
+func TestSlices(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"d[1:2]", "d[1:2:nil]", ""},
+		{"d[::2]", "d[nil:nil:2]", ""},
+		{"d[-1::-1]", "d[(- 1):nil:(- 1)]", ""},
+		{"d[-1]", "d[(- 1)]", ""},
+		{"d[1:2:3:4]", "", "expected ]"},
+	})
+}
+
+func TestProtocolStatement(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{"protocol P { load(self, path), name }", "protocol P {load(self, path), name}", ""},
+		{"protocol P { }", "protocol P {}", ""},
+		{"protocol { a }", "", "expected protocol identifier"},
+		{"protocol P { 1 }", "", "expected protocol member"},
+		{"protocol P { f(1) }", "", "expected parameter"},
+	})
+}
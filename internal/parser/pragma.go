@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"wildscript/internal/ast"
+	"wildscript/internal/lexer"
+)
+
+// edition directives then pragma statements, both only at the top of module,
+// pragma name with no_ prefix turns behavior off
+func (p *Parser) parsePragmas() ast.Pragmas {
+	edition := ast.LatestEdition
+	for p.curToken.Type == lexer.DIRECTIVE {
+		fields := strings.Fields(p.curToken.Literal)
+		if len(fields) != 2 || fields[0] != "edition" {
+			die(p.curToken, "unknown directive: %s", p.curToken.Literal)
+		}
+		number, err := strconv.Atoi(fields[1])
+		if err != nil || number < 1 || number > ast.LatestEdition {
+			die(p.curToken, "unknown edition: %s", fields[1])
+		}
+		edition = number
+		p.nextToken() // to next directive or statement
+	}
+
+	pragmas := ast.EditionPragmas(edition)
+	for p.curToken.Type == lexer.PRAGMA {
+		if p.peekToken.Type != lexer.IDENTIFIER {
+			p.expected("pragma name")
+		}
+		p.nextToken() // to name
+
+		name := p.curToken.Literal
+		on := !strings.HasPrefix(name, "no_")
+		switch strings.TrimPrefix(name, "no_") {
+		case "strict":
+			pragmas.Strict = on
+		case "asi":
+			pragmas.ASI = on
+		case "short_circuit":
+			pragmas.ShortCircuit = on
+		default:
+			die(p.curToken, "unknown pragma: %s", name)
+		}
+
+		switch {
+		case p.peekToken.Type == lexer.SEMICOLON:
+			p.nextToken() // to ;
+		case p.peekToken.Type == lexer.EOF, p.peekToken.NewLine:
+		default:
+			p.expected("; or new line")
+		}
+		p.nextToken() // to next pragma or statement
+	}
+
+	return pragmas
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"maps"
+	"wildscript/internal/ast"
+	"wildscript/internal/lexer"
+)
+
+// parses expression starting at current token,
+// leaves current token at the end of expression
+type PrefixParseFn func(p *Parser) ast.Expression
+
+// parses expression with operator at current token
+type InfixParseFn func(p *Parser, left ast.Expression) ast.Expression
+
+var (
+	prefixParseFns = map[lexer.TokenType]PrefixParseFn{}
+	infixParseFns  = map[lexer.TokenType]InfixParseFn{}
+)
+
+// affects parsers created after registration
+func RegisterPrefix(tokenType lexer.TokenType, fn PrefixParseFn) {
+	prefixParseFns[tokenType] = fn
+}
+
+// affects parsers created after registration
+func RegisterInfix(tokenType lexer.TokenType, precedence int, fn InfixParseFn) {
+	infixParseFns[tokenType] = fn
+	precedences[tokenType] = precedence
+}
+
+func (p *Parser) RegisterPrefix(tokenType lexer.TokenType, fn PrefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
+
+func (p *Parser) RegisterInfix(tokenType lexer.TokenType, precedence int, fn InfixParseFn) {
+	p.infixParseFns[tokenType] = fn
+	p.precedences[tokenType] = precedence
+}
+
+func (p *Parser) loadRegistry() {
+	p.prefixParseFns = maps.Clone(prefixParseFns)
+	p.infixParseFns = maps.Clone(infixParseFns)
+	p.precedences = maps.Clone(precedences)
+}
+
+func init() {
+	RegisterPrefix(lexer.NOT, func(p *Parser) ast.Expression {
+		return p.parsePrefixExpression()
+	})
+	RegisterPrefix(lexer.MINUS, func(p *Parser) ast.Expression {
+		return p.parsePrefixExpression()
+	})
+	RegisterPrefix(lexer.LPAREN, func(p *Parser) ast.Expression {
+		return p.parseGroupedExpression()
+	})
+	RegisterPrefix(lexer.IDENTIFIER, func(p *Parser) ast.Expression {
+		return p.parseIdentifier()
+	})
+	RegisterPrefix(lexer.IF, func(p *Parser) ast.Expression {
+		return p.parseIfExpression()
+	})
+	RegisterPrefix(lexer.ELIF, func(p *Parser) ast.Expression {
+		return p.parseIfExpression()
+	})
+	RegisterPrefix(lexer.LAMBDA, func(p *Parser) ast.Expression {
+		p.nextToken() // to (
+		return p.parseFunctionLiteral(ast.LAMBDA)
+	})
+	RegisterPrefix(lexer.METHOD, func(p *Parser) ast.Expression {
+		p.nextToken() // to (
+		return p.parseFunctionLiteral(ast.METHOD)
+	})
+	RegisterPrefix(lexer.STATIC, func(p *Parser) ast.Expression {
+		p.nextToken() // to (
+		return p.parseFunctionLiteral(ast.STATIC)
+	})
+	RegisterPrefix(lexer.LBRACE, func(p *Parser) ast.Expression {
+		return p.parseDocumentLiteral()
+	})
+	RegisterPrefix(lexer.NUMBER, func(p *Parser) ast.Expression {
+		return p.parseNumberLiteral()
+	})
+	RegisterPrefix(lexer.STRING, func(p *Parser) ast.Expression {
+		return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	})
+	RegisterPrefix(lexer.TRUE, func(p *Parser) ast.Expression {
+		return &ast.BooleanLiteral{Token: p.curToken, Value: true}
+	})
+	RegisterPrefix(lexer.FALSE, func(p *Parser) ast.Expression {
+		return &ast.BooleanLiteral{Token: p.curToken, Value: false}
+	})
+	RegisterPrefix(lexer.NIL, func(p *Parser) ast.Expression {
+		return &ast.NilLiteral{Token: p.curToken}
+	})
+	RegisterPrefix(lexer.QUOTE, func(p *Parser) ast.Expression {
+		return p.parseQuoteExpression()
+	})
+	RegisterPrefix(lexer.UNQUOTE, func(p *Parser) ast.Expression {
+		return p.parseUnquoteExpression()
+	})
+
+	for _, tokenType := range []lexer.TokenType{
+		lexer.OR, lexer.AND,
+		lexer.EQUAL, lexer.NOT_EQUAL,
+		lexer.LESS, lexer.GREATER, lexer.LESS_EQ, lexer.GREATER_EQ,
+		lexer.PLUS, lexer.MINUS,
+		lexer.MULTIPLY, lexer.DIVIDE, lexer.INT_DIVIDE, lexer.MOD,
+		lexer.POW,
+	} {
+		RegisterInfix(tokenType, precedences[tokenType], func(p *Parser, left ast.Expression) ast.Expression {
+			return p.parseInfixExpression(left)
+		})
+	}
+	RegisterInfix(lexer.DOT, CALL, func(p *Parser, left ast.Expression) ast.Expression {
+		return p.parseAttributeExpression(left)
+	})
+	RegisterInfix(lexer.LBRACKET, CALL, func(p *Parser, left ast.Expression) ast.Expression {
+		return p.parseBracketExpression(left)
+	})
+	RegisterInfix(lexer.LBRACE, CALL, func(p *Parser, left ast.Expression) ast.Expression {
+		return p.parseKeyExpression(left)
+	})
+	RegisterInfix(lexer.LPAREN, CALL, func(p *Parser, left ast.Expression) ast.Expression {
+		return p.parseCallExpression(left)
+	})
+}
+
+// helpers for registered parse functions
+
+func (p *Parser) CurToken() lexer.Token {
+	return p.curToken
+}
+
+func (p *Parser) PeekToken() lexer.Token {
+	return p.peekToken
+}
+
+func (p *Parser) NextToken() {
+	p.nextToken()
+}
+
+func (p *Parser) ParseExpression(precedence int) ast.Expression {
+	return p.parseExpression(precedence)
+}
+
+// dies with expected text at peek token
+func (p *Parser) Expected(text string) {
+	p.expected(text)
+}
@@ -21,6 +21,23 @@ func (p *Parser) parseStatement() ast.Statement {
 		stmt = p.parseForStatement()
 	case lexer.REPEAT:
 		stmt = p.parseRepeatStatement()
+	case lexer.WITH:
+		stmt = p.parseWithStatement()
+	case lexer.ENUM:
+		stmt = p.parseEnumStatement()
+	case lexer.PROTOCOL:
+		stmt = p.parseProtocolStatement()
+	case lexer.DEL:
+		stmt = p.parseDeleteStatement()
+	case lexer.OPERATOR:
+		if p.depth != 0 {
+			die(p.curToken, "operator must be at the top of module")
+		}
+		stmt = p.parseOperatorStatement()
+	case lexer.MACRO:
+		stmt = p.parseMacroStatement()
+	case lexer.PRAGMA:
+		die(p.curToken, "pragma must be at the top of module")
 	case lexer.LET:
 		letStmt := &ast.LetStatement{
 			Token: p.curToken,
@@ -31,9 +48,7 @@ func (p *Parser) parseStatement() ast.Statement {
 		p.nextToken() // to ident
 		letStmt.Left = p.parseIdentifier()
 
-		if p.peekToken.Type == lexer.SEMICOLON ||
-			p.peekToken.Type == lexer.EOF ||
-			p.peekToken.Type == lexer.RBRACE {
+		if p.peekEndsStatement() {
 			letStmt.Right = &ast.NilLiteral{Token: p.curToken}
 			stmt = letStmt
 		} else {
@@ -64,9 +79,7 @@ func (p *Parser) parseStatement() ast.Statement {
 		exportStmt.Value = p.parseExpression(LOWEST)
 		stmt = exportStmt
 	case lexer.RETURN:
-		if p.peekToken.Type == lexer.SEMICOLON ||
-			p.peekToken.Type == lexer.RBRACE ||
-			p.peekToken.Type == lexer.EOF {
+		if p.peekEndsStatement() {
 			stmt = &ast.ReturnStatement{
 				Token: p.curToken,
 				Value: &ast.NilLiteral{
@@ -130,13 +143,19 @@ func (p *Parser) parseStatement() ast.Statement {
 		die(p.curToken, "nil statement")
 	}
 
-	if p.peekToken.Type != lexer.SEMICOLON &&
-		p.peekToken.Type != lexer.EOF &&
-		p.peekToken.Type != lexer.RBRACE {
+	switch {
+	case p.peekToken.Type == lexer.SEMICOLON,
+		p.peekToken.Type == lexer.EOF,
+		p.peekToken.Type == lexer.RBRACE:
+		p.nextToken() // to ; or EOF
+	case p.asi && p.peekToken.NewLine:
+		p.insertSemicolon()
+	case p.asi:
+		p.expected("; or } or new line")
+	default:
 		p.expected("; or }")
 	}
 
-	p.nextToken() // to ; or EOF
 	return stmt
 }
 
@@ -188,6 +207,23 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 	p.nextToken() // to ident or expr
 	expr := p.parseExpression(LOWEST)
 
+	if p.peekToken.Type == lexer.COMMA {
+		ident, ok := expr.(*ast.Identifier)
+		if !ok {
+			die(p.curToken, "expected identifier before ,")
+		}
+		stmt.Key = ident
+		p.nextToken() // to ,
+		if p.peekToken.Type != lexer.IDENTIFIER {
+			p.expected("identifier")
+		}
+		p.nextToken() // to ident
+		expr = p.parseIdentifier()
+		if p.peekToken.Type != lexer.IN {
+			p.expected("in")
+		}
+	}
+
 	if p.peekToken.Type == lexer.IN {
 		ident, ok := expr.(*ast.Identifier)
 		if !ok {
@@ -213,3 +249,237 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 
 	return stmt
 }
+
+func (p *Parser) parseWithStatement() *ast.WithStatement {
+	stmt := &ast.WithStatement{
+		Token: p.curToken,
+	}
+	p.nextToken() // to resource
+	stmt.Resource = p.parseExpression(LOWEST)
+
+	if p.peekToken.Type == lexer.AS {
+		p.nextToken() // to as
+		if p.peekToken.Type != lexer.IDENTIFIER {
+			p.expected("identifier")
+		}
+		p.nextToken() // to ident
+		stmt.Name = p.parseIdentifier()
+	}
+
+	if p.peekToken.Type != lexer.DO {
+		p.expected("do")
+	}
+
+	p.nextToken() // to do
+	if p.peekToken.Type != lexer.LBRACE {
+		p.expected("{")
+	}
+	p.nextToken() // to {
+	stmt.Body = p.parseBlockExpression()
+
+	return stmt
+}
+
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{
+		Token: p.curToken,
+	}
+	if p.peekToken.Type != lexer.IDENTIFIER {
+		p.expected("enum identifier")
+	}
+	p.nextToken() // to ident
+	stmt.Name = p.parseIdentifier()
+
+	if p.peekToken.Type != lexer.LBRACE {
+		p.expected("{")
+	}
+	p.nextToken() // to {
+
+	for p.peekToken.Type != lexer.RBRACE {
+		if p.peekToken.Type != lexer.IDENTIFIER {
+			p.expected("enum member")
+		}
+		p.nextToken() // to ident
+		member := &ast.EnumMember{
+			Token: p.curToken,
+			Name:  p.parseIdentifier(),
+		}
+		if p.peekToken.Type == lexer.ASSIGN {
+			p.nextToken() // to =
+			p.nextToken() // to value
+			member.Value = p.parseExpression(LOWEST)
+		}
+		stmt.Members = append(stmt.Members, member)
+
+		if p.peekToken.Type != lexer.COMMA {
+			break
+		}
+		p.nextToken() // to ,
+	}
+
+	if p.peekToken.Type != lexer.RBRACE {
+		p.expected("}")
+	}
+	p.nextToken() // to }
+
+	return stmt
+}
+
+func (p *Parser) parseDeleteStatement() *ast.DeleteStatement {
+	stmt := &ast.DeleteStatement{
+		Token: p.curToken,
+	}
+	p.nextToken() // to target
+	token := p.curToken
+	stmt.Target = p.parseExpression(LOWEST)
+
+	switch target := stmt.Target.(type) {
+	case *ast.AttributeExpression, *ast.SliceExpression:
+	case *ast.IndexExpression:
+		if _, ok := target.Index.(*ast.NilLiteral); ok {
+			die(target.Token, "del needs index")
+		}
+	case *ast.KeyExpression:
+		if target.Whole() {
+			die(target.Token, "del needs key")
+		}
+	default:
+		die(token, "can not delete %s", stmt.Target.String())
+	}
+
+	return stmt
+}
+
+// members are attribute names, with parameters they must be functions
+func (p *Parser) parseProtocolStatement() *ast.ProtocolStatement {
+	stmt := &ast.ProtocolStatement{
+		Token: p.curToken,
+	}
+	if p.peekToken.Type != lexer.IDENTIFIER {
+		p.expected("protocol identifier")
+	}
+	p.nextToken() // to ident
+	stmt.Name = p.parseIdentifier()
+
+	if p.peekToken.Type != lexer.LBRACE {
+		p.expected("{")
+	}
+	p.nextToken() // to {
+
+	for p.peekToken.Type != lexer.RBRACE {
+		if p.peekToken.Type != lexer.IDENTIFIER {
+			p.expected("protocol member")
+		}
+		p.nextToken() // to ident
+		member := &ast.ProtocolMember{
+			Token: p.curToken,
+			Name:  p.parseIdentifier(),
+		}
+		if p.peekToken.Type == lexer.LPAREN {
+			p.nextToken()                                   // to (
+			member.Parameters = p.parseFunctionParameters() // include )
+		}
+		stmt.Members = append(stmt.Members, member)
+
+		if p.peekToken.Type != lexer.COMMA {
+			break
+		}
+		p.nextToken() // to ,
+	}
+
+	if p.peekToken.Type != lexer.RBRACE {
+		p.expected("}")
+	}
+	p.nextToken() // to }
+
+	return stmt
+}
+
+// registers infix parse function for the rest of the module,
+// precedence uses the scale of built-in operators,
+// only top-level so that function is bound in module environment
+func (p *Parser) parseOperatorStatement() *ast.OperatorStatement {
+	stmt := &ast.OperatorStatement{
+		Token: p.curToken,
+	}
+
+	symbol := p.peekToken
+	if symbol.Literal == "" || symbol.Type != lexer.TokenType(symbol.Literal) {
+		p.expected("operator symbol")
+	}
+	if lexer.IsReservedSymbol(symbol.Literal) {
+		die(symbol, "operator %s is reserved", symbol.Literal)
+	}
+	if _, ok := p.infixParseFns[symbol.Type]; ok {
+		die(symbol, "operator %s already defined", symbol.Literal)
+	}
+	p.nextToken() // to symbol
+	stmt.Operator = symbol.Literal
+
+	if p.peekToken.Type != lexer.NUMBER {
+		p.expected("precedence")
+	}
+	p.nextToken() // to precedence
+	precedence := p.parseNumberLiteral().Value
+	if precedence != float64(int(precedence)) ||
+		precedence <= LOWEST || precedence >= CALL {
+		die(p.curToken, "precedence must be integer from %d to %d", LOWEST+1, CALL-1)
+	}
+	stmt.Precedence = int(precedence)
+
+	if p.peekToken.Literal != "left" && p.peekToken.Literal != "right" {
+		p.expected("left or right")
+	}
+	p.nextToken() // to associativity
+	stmt.Associativity = p.curToken.Literal
+
+	if p.peekToken.Type != lexer.ASSIGN {
+		p.expected("=")
+	}
+	p.nextToken() // to =
+	p.nextToken() // to function
+	stmt.Function = p.parseExpression(LOWEST)
+
+	rightPrecedence := stmt.Precedence
+	if stmt.Associativity == "right" {
+		rightPrecedence--
+	}
+	p.RegisterInfix(symbol.Type, stmt.Precedence, func(p *Parser, left ast.Expression) ast.Expression {
+		expr := &ast.InfixExpression{
+			Token:    p.curToken,
+			Operator: p.curToken.Literal,
+			Left:     left,
+		}
+		p.nextToken() // to right expr
+		expr.Right = p.parseExpression(rightPrecedence)
+		return expr
+	})
+
+	return stmt
+}
+
+func (p *Parser) parseMacroStatement() *ast.MacroStatement {
+	stmt := &ast.MacroStatement{
+		Token: p.curToken,
+	}
+	if p.peekToken.Type != lexer.IDENTIFIER {
+		p.expected("macro identifier")
+	}
+	p.nextToken() // to ident
+	stmt.Name = p.parseIdentifier()
+
+	if p.peekToken.Type != lexer.LPAREN {
+		p.expected("(")
+	}
+	p.nextToken() // to (
+
+	stmt.Parameters = p.parseFunctionParameters() // include )
+
+	if p.peekToken.Type != lexer.LBRACE {
+		p.expected("{")
+	}
+	p.nextToken() // to {
+	stmt.Body = p.parseBlockExpression()
+
+	return stmt
+}
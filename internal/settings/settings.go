@@ -5,8 +5,9 @@ var Global *Settings
 type Settings struct {
 	Debug  bool
 	Tokens bool
+	Color  string // auto, always or never
 }
 
 func init() {
-	Global = &Settings{}
+	Global = &Settings{Color: "auto"}
 }